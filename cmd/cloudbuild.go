@@ -26,6 +26,11 @@ var cloudBuildLongExample = `
 Export encrypted secrets to your cloudbuild.yaml file
 
 gvault cloudbuild >> cloudbuild.yaml
+
+Cloud Build decrypts every secret with the KMS key directly and without additional
+authenticated data. Only vaults storing secrets that way can be exported, which does not
+require any credentials. Create such a vault with gvault init --cloudbuild or copy an
+existing one with gvault vaults clone SOURCE DESTINATION --cloudbuild.
 `
 
 // cloudbuildCmd represents the cloudbuild command
@@ -35,10 +40,15 @@ var cloudbuildCmd = &cobra.Command{
 	Long:    cloudBuildLongExample,
 	PreRunE: vault.EsureVaultLoaded(gvault),
 	Run: func(cmd *cobra.Command, args []string) {
+		secrets, err := gvault.KmsSecrets()
+		if err != nil {
+			logger.Fatal(err)
+		}
+
 		build := cloudbuild.Build{}
 		secret := cloudbuild.Secret{
 			KmsKeyName: gvault.KmsKeyName(),
-			SecretEnv:  gvault.Secrets,
+			SecretEnv:  secrets,
 		}
 
 		build.Secrets = append(build.Secrets, secret)
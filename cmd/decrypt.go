@@ -52,7 +52,14 @@ var decryptCmd = &cobra.Command{
 			cipherText = args[0]
 		}
 
-		decrypted, err := gvault.Crypter.Decrypt(cipherText)
+		if err := gvault.EnsureCrypter(); err != nil {
+			logger.Fatal(err)
+		}
+
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		decrypted, err := gvault.Crypter.Decrypt(ctx, cipherText, nil)
 
 		if err != nil {
 			logger.Fatal(err)
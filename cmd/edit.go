@@ -0,0 +1,310 @@
+// Copyright © 2018 James Qualls https://github.com/sourcec0de
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"syscall"
+
+	"github.com/chzyer/readline"
+	"github.com/ghodss/yaml"
+	"github.com/joho/godotenv"
+	"github.com/pkg/errors"
+	"github.com/sourcec0de/gvault/utils"
+	"github.com/sourcec0de/gvault/vault"
+	"github.com/spf13/cobra"
+)
+
+var editLongExample = `
+Edit the secrets of a vault in your editor
+
+$ gvault edit
+$ EDITOR="code --wait" gvault edit --format yaml
+
+The vault is decrypted to a private temporary file (on /dev/shm when available) which
+is removed afterwards. Only added and changed secrets are encrypted again, unchanged
+secrets keep their cipher text.
+`
+
+// editCmd represents the edit command
+var editCmd = &cobra.Command{
+	Use:     "edit",
+	Short:   "Edit the secrets of a vault in $EDITOR",
+	Long:    editLongExample,
+	Args:    cobra.NoArgs,
+	PreRunE: vault.EsureVaultLoaded(gvault),
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		if format != "env" && format != "yaml" {
+			logger.Fatalf("%s is not a supported edit format, use env or yaml", format)
+		}
+
+		ctx, cancel := commandContext()
+		decrypted, err := gvault.DecryptedSecrets(ctx)
+		cancel()
+
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		// binary secrets can not be edited as text and are left untouched
+		original := map[string]string{}
+		for name, value := range decrypted {
+			if !gvault.IsBinary(name) {
+				original[name] = value
+			} else {
+				logger.Infof("Skipping binary secret %s", name)
+			}
+		}
+
+		edited, err := editSecrets(original, format)
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		changed := map[string]string{}
+		removed := []string{}
+
+		for _, name := range sortedKeys(edited) {
+			if gvault.IsBinary(name) {
+				logger.Fatalf("%s is a binary secret and can not be replaced by editing", name)
+			}
+
+			if value, ok := original[name]; !ok {
+				fmt.Printf("+ %s\n", name)
+				changed[name] = edited[name]
+			} else if value != edited[name] {
+				fmt.Printf("~ %s\n", name)
+				changed[name] = edited[name]
+			}
+		}
+
+		for _, name := range sortedKeys(original) {
+			if _, ok := edited[name]; !ok {
+				fmt.Printf("- %s\n", name)
+				removed = append(removed, name)
+			}
+		}
+
+		if len(changed) == 0 && len(removed) == 0 {
+			fmt.Println("No changes")
+			return
+		}
+
+		ctx, cancel = commandContext()
+		importErr := gvault.ImportEnvMap(ctx, changed)
+		cancel()
+
+		if importErr != nil {
+			logger.Fatal(importErr)
+		}
+
+		for _, name := range removed {
+			gvault.RemoveSecret(name)
+		}
+
+		if err := gvault.Save(); err != nil {
+			logger.Fatal(err)
+		}
+	},
+}
+
+// editSecrets writes secrets to a private temporary file, opens it in the editor
+// and returns the edited secrets. The user may edit again if the file can not be parsed
+func editSecrets(secrets map[string]string, format string) (map[string]string, error) {
+	content, err := marshalEditable(secrets, format)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := ioutil.TempDir(privateTempDir(), "gvault-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temporary directory")
+	}
+	defer os.RemoveAll(dir)
+
+	stop := removeOnSignal(dir)
+	defer stop()
+
+	path := filepath.Join(dir, gvault.Name+"."+format)
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		return nil, err
+	}
+
+	for {
+		if err := runEditor(path); err != nil {
+			return nil, err
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		edited, parseErr := unmarshalEditable(data, format)
+		if parseErr == nil {
+			parseErr = validateNames(edited)
+		}
+		if parseErr == nil {
+			return edited, nil
+		}
+
+		if !utils.IsTerminal() {
+			return nil, parseErr
+		}
+
+		rl, err := readline.New("")
+		if err != nil {
+			return nil, err
+		}
+		again := utils.Confirm(fmt.Sprintf("%s. Edit again?", parseErr), rl)
+		rl.Close()
+
+		if !again {
+			return nil, parseErr
+		}
+	}
+}
+
+// validateNames checks that every edited secret can be used as an environment variable
+func validateNames(secrets map[string]string) error {
+	for _, name := range sortedKeys(secrets) {
+		if err := utils.ValidateEnvName(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeOnSignal keeps decrypted secrets from being left behind when gvault is signalled
+// while the editor is open. Interrupts reach the editor through the terminal and are
+// ignored so the deferred cleanup runs once it exits, termination removes dir and exits
+func removeOnSignal(dir string) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+
+	go func() {
+		for sig := range signals {
+			if sig == os.Interrupt {
+				continue
+			}
+			os.RemoveAll(dir)
+			logger.Fatalf("Received %s, removed the decrypted secrets", sig)
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(signals)
+	}
+}
+
+// marshalEditable renders secrets for editing. The rendering has to parse back to the
+// same values, otherwise unchanged secrets would be silently altered
+func marshalEditable(secrets map[string]string, format string) ([]byte, error) {
+	var content []byte
+
+	if format == "yaml" {
+		bytes, err := yaml.Marshal(secrets)
+		if err != nil {
+			return nil, err
+		}
+		content = bytes
+	} else {
+		env, err := godotenv.Marshal(secrets)
+		if err != nil {
+			return nil, err
+		}
+		content = []byte(env + "\n")
+	}
+
+	parsed, err := unmarshalEditable(content, format)
+	if err != nil || !reflect.DeepEqual(parsed, secrets) {
+		return nil, fmt.Errorf("some secrets can not be edited as %s, try --format yaml", format)
+	}
+
+	return content, nil
+}
+
+// unmarshalEditable parses an edited file
+func unmarshalEditable(data []byte, format string) (map[string]string, error) {
+	secrets := map[string]string{}
+
+	if format == "yaml" {
+		if err := yaml.Unmarshal(data, &secrets); err != nil {
+			return nil, errors.Wrap(err, "invalid YAML")
+		}
+		return secrets, nil
+	}
+
+	secrets, err := godotenv.Unmarshal(string(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid dotenv file")
+	}
+	return secrets, nil
+}
+
+// privateTempDir prefers tmpfs so plain texts are never written to disk
+func privateTempDir() string {
+	if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+		return "/dev/shm"
+	}
+	return os.TempDir()
+}
+
+// runEditor opens path in $VISUAL or $EDITOR, falling back to vi
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// run through the shell so editors with arguments like "code --wait" work
+	editorCmd := exec.Command("sh", "-c", editor+` "$1"`, "gvault-edit", path)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+
+	if err := editorCmd.Run(); err != nil {
+		return errors.Wrapf(err, "editor %s failed", editor)
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+
+	editCmd.Flags().String("format", "env", "The format to edit the secrets in (env, yaml)")
+}
@@ -51,7 +51,14 @@ var encryptCmd = &cobra.Command{
 			plainText = []byte(args[0])
 		}
 
-		encryptedData, err := gvault.Crypter.Encrypt(plainText)
+		if err := gvault.EnsureCrypter(); err != nil {
+			logger.Fatal(err)
+		}
+
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		encryptedData, err := gvault.Crypter.Encrypt(ctx, plainText, nil)
 
 		if err != nil {
 			logger.Fatal(err)
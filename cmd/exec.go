@@ -0,0 +1,148 @@
+// Copyright © 2018 James Qualls https://github.com/sourcec0de
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/sourcec0de/gvault/utils"
+	"github.com/sourcec0de/gvault/vault"
+	"github.com/spf13/cobra"
+)
+
+var execLongExample = `
+Run a command with the vault secrets as environment variables
+
+$ gvault exec -- node server.js
+$ gvault exec --vault production --precedence env -- ./migrate.sh
+
+Secrets are only decrypted into the environment of the child process. Signals are
+forwarded to it and gvault exits with its exit code.
+
+--precedence decides which value is used when a secret has the same name as an
+existing environment variable: vault (default) or env.
+`
+
+// execCmd represents the exec command
+var execCmd = &cobra.Command{
+	Use:     "exec -- COMMAND [ARGS...]",
+	Short:   "Run a command with the vault secrets injected as environment variables",
+	Long:    execLongExample,
+	Args:    cobra.MinimumNArgs(1),
+	PreRunE: vault.EsureVaultLoaded(gvault),
+	Run: func(cmd *cobra.Command, args []string) {
+		precedence, _ := cmd.Flags().GetString("precedence")
+		if precedence != "vault" && precedence != "env" {
+			logger.Fatalf("%s is not a valid precedence, use vault or env", precedence)
+		}
+
+		ctx, cancel := commandContext()
+		decryptErr := gvault.DecryptAll(ctx)
+		cancel()
+
+		if decryptErr != nil {
+			logger.Fatal(decryptErr)
+		}
+
+		child := exec.Command(args[0], args[1:]...)
+		child.Env = mergeEnv(os.Environ(), gvault.TextSecrets(), precedence == "vault")
+		child.Stdin = os.Stdin
+		child.Stdout = os.Stdout
+		child.Stderr = os.Stderr
+
+		// on a terminal the child is in the foreground process group and receives
+		// keyboard signals itself, forwarding them again would deliver them twice
+		interactive := utils.IsTerminal()
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+
+		if err := child.Start(); err != nil {
+			logger.Fatal(err)
+		}
+
+		go func() {
+			for sig := range signals {
+				if interactive && (sig == os.Interrupt || sig == syscall.SIGQUIT) {
+					continue
+				}
+				child.Process.Signal(sig)
+			}
+		}()
+
+		waitErr := child.Wait()
+		signal.Stop(signals)
+		close(signals)
+
+		os.Exit(exitCode(waitErr))
+	},
+}
+
+// mergeEnv adds secrets to environ. When override is false existing variables are kept
+func mergeEnv(environ []string, secrets map[string]string, override bool) []string {
+	merged := []string{}
+	existing := map[string]bool{}
+
+	for _, entry := range environ {
+		name := strings.SplitN(entry, "=", 2)[0]
+		if _, isSecret := secrets[name]; isSecret && override {
+			continue
+		}
+		existing[name] = true
+		merged = append(merged, entry)
+	}
+
+	for name, value := range secrets {
+		if !existing[name] {
+			merged = append(merged, name+"="+value)
+		}
+	}
+
+	return merged
+}
+
+// exitCode the exit code of a finished child process following shell conventions
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		logger.Error(err)
+		return 1
+	}
+
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+		if status.Signaled() {
+			return 128 + int(status.Signal())
+		}
+		return status.ExitStatus()
+	}
+
+	logger.Error(err)
+	return 1
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+
+	// flags after the command belong to the command
+	execCmd.Flags().SetInterspersed(false)
+	execCmd.Flags().String("precedence", "vault", "Which value wins when a secret and an environment variable share a name (vault, env)")
+}
@@ -16,19 +16,40 @@ package cmd
 
 import (
 	"fmt"
-
-	log "github.com/sirupsen/logrus"
+	"strings"
 
 	"github.com/chzyer/readline"
+	"github.com/sourcec0de/gvault/crypter"
 	"github.com/sourcec0de/gvault/utils"
+	"github.com/sourcec0de/gvault/vault"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
 )
 
+var initLongExample = `
+Initialize a new vault
+
+$ gvault init
+
+Settings that are not supplied as flags or GVAULT_* environment variables are prompted for
+when running in a terminal. Without a terminal missing settings are an error, e.g. in CI:
+
+$ gvault init --project my-project --location global --keyring my-keyring --key my-key
+
+$ GVAULT_BACKEND=local GVAULT_KEY=ci gvault init
+
+Vaults exported with gvault cloudbuild have to encrypt secrets directly with the KMS key,
+without envelope encryption and tamper protection:
+
+$ gvault init --cloudbuild
+`
+
 // initCmd represents the init command
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize a new gvault",
-	Long:  ``,
+	Long:  initLongExample,
 	Args: func(cmd *cobra.Command, args []string) error {
 		if exists, err := gvault.Exists(); exists || err != nil {
 			if exists {
@@ -39,29 +60,51 @@ var initCmd = &cobra.Command{
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		rl, err := readline.NewEx(&readline.Config{
-			UniqueEditLine: true,
-		})
+		prompter := &initPrompter{}
+
+		if utils.IsTerminal() {
+			rl, err := readline.NewEx(&readline.Config{
+				UniqueEditLine: true,
+			})
 
-		if err != nil {
-			log.Fatal(err)
+			if err != nil {
+				logger.Fatal(err)
+			}
+
+			defer rl.Close()
+			prompter.rl = rl
 		}
 
-		defer rl.Close()
+		ctx, cancel := commandContext()
+		defer cancel()
 
-		project, _ := utils.Ask("Google Cloud ProjectID: ", rl)
-		keyring, _ := utils.Ask("Google KMS Keyring: ", rl)
-		location, _ := utils.Ask("Google KMS Keyring Location (defaults to global): ", rl)
-		key, _ := utils.Ask("Google KMS Key: ", rl)
+		backend := prompter.value("backend", "", crypter.BackendKMS)
 
-		if location == "" {
-			location = "global"
+		gvault.CloudBuild = viper.GetBool("cloudbuild")
+		if gvault.CloudBuild && backend != crypter.BackendKMS {
+			logger.Fatalf("--cloudbuild requires the kms backend, not %s", backend)
 		}
 
-		gvault.Project = project
-		gvault.Keyring = keyring
-		gvault.Location = location
-		gvault.Key = key
+		if backend == crypter.BackendLocal {
+			gvault.Key = prompter.value("key", fmt.Sprintf("Local key name (defaults to %s): ", gvault.Name), gvault.Name)
+		} else {
+			gvault.Project = prompter.value("project", "Google Cloud ProjectID: ", "")
+			gvault.Location = prompter.value("location", "Google KMS Keyring Location (defaults to global): ", "global")
+			prompter.check()
+
+			gvault.Keyring, gvault.Key = chooseKMSKey(ctx, prompter, backend, gvault.Project, gvault.Location)
+
+			if backend == crypter.BackendKMSAsymmetric {
+				gvault.KeyVersion = prompter.value("key-version", "Google KMS Key Version (defaults to 1): ", "1")
+			}
+		}
+
+		prompter.check()
+		gvault.Backend = backend
+
+		if err := setupVaultKey(ctx, gvault); err != nil {
+			logger.Fatal(err)
+		}
 
 		if saveErr := gvault.Save(); saveErr != nil {
 			logger.Fatal(saveErr)
@@ -71,16 +114,191 @@ var initCmd = &cobra.Command{
 	},
 }
 
+// setupVaultKey prepares the key settings of a new vault. Local keys are created,
+// asymmetric vaults cache the public key and all others except Cloud Build vaults get a data key
+func setupVaultKey(ctx context.Context, v *vault.Vault) error {
+	if err := v.InitCrypter(); err != nil {
+		return err
+	}
+
+	if localCrypter, ok := v.Crypter.(*crypter.LocalCrypter); ok {
+		if err := localCrypter.CreateKeyIfNotExists(); err != nil {
+			return err
+		}
+		logger.Infof("Using local key %s", localCrypter.KeyPath())
+	}
+
+	if v.CloudBuild {
+		// Cloud Build decrypts with the KMS key directly and without additional authenticated data
+		if v.Backend != crypter.BackendKMS {
+			return fmt.Errorf("--cloudbuild requires the kms backend, not %s", v.Backend)
+		}
+		return nil
+	}
+
+	v.AAD = true
+
+	if v.Backend == crypter.BackendKMSAsymmetric {
+		// secrets are encrypted locally with the public key so users
+		// without decrypt permissions are still able to add them
+		return v.FetchPublicKey(ctx)
+	}
+	return v.EnableEnvelopeEncryption(ctx)
+}
+
+// initPrompter resolves init settings from flags and environment variables
+// and only prompts for missing ones when attached to a terminal
+type initPrompter struct {
+	rl      *readline.Instance
+	missing []string
+}
+
+// interactive reports whether the user can be prompted
+func (p *initPrompter) interactive() bool {
+	return p.rl != nil
+}
+
+// value returns the setting for key from its flag or GVAULT_* environment variable,
+// otherwise prompts with question. Missing settings without a default are recorded
+func (p *initPrompter) value(key, question, defaultValue string) string {
+	if value := viper.GetString(key); value != "" {
+		return value
+	}
+
+	if p.interactive() && question != "" {
+		if answer, _ := utils.Ask(question, p.rl); answer != "" {
+			return answer
+		}
+	}
+
+	if defaultValue == "" {
+		p.missing = append(p.missing, key)
+	}
+
+	return defaultValue
+}
+
+// confirm asks a yes / no question, answering no when not attached to a terminal
+func (p *initPrompter) confirm(question string) bool {
+	return p.interactive() && utils.Confirm(question, p.rl)
+}
+
+// check exits if any required setting is missing
+func (p *initPrompter) check() {
+	if len(p.missing) == 0 {
+		return
+	}
+
+	flags := []string{}
+	for _, key := range p.missing {
+		envName := "GVAULT_" + strings.ToUpper(strings.Replace(key, "-", "_", -1))
+		flags = append(flags, fmt.Sprintf("--%s (%s)", key, envName))
+	}
+
+	logger.Fatalf("Missing required settings: %s", strings.Join(flags, ", "))
+}
+
+// chooseKMSKey lets the user pick an existing keyring and crypto key from the project
+// and offers to create them when they do not exist yet. With --create missing keys
+// are created without asking
+func chooseKMSKey(ctx context.Context, p *initPrompter, backend, project, location string) (string, string) {
+	create := viper.GetBool("create")
+
+	if !p.interactive() && !create {
+		return p.value("keyring", "", ""), p.value("key", "", "")
+	}
+
+	manager, err := crypter.NewKeyManager(ctx, gvault.Options)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// users allowed to encrypt are not necessarily allowed to list keys
+	// so failing to list only disables the choices and creating new keys
+	keyrings, listErr := manager.KeyRings(ctx, project, location)
+	if listErr != nil {
+		logger.Warnf("Unable to list keyrings: %s", listErr)
+	}
+
+	keyring := viper.GetString("keyring")
+	if keyring == "" && p.interactive() {
+		keyring, _ = utils.Choose("Google KMS Keyring: ", keyrings, p.rl)
+	}
+
+	if keyring == "" {
+		p.missing = append(p.missing, "keyring")
+		p.check()
+	}
+
+	keyringExists := utils.Contains(keyrings, keyring)
+
+	if listErr == nil && !keyringExists {
+		if !create && !p.confirm(fmt.Sprintf("Keyring (%s) does not exist in %s. Create it?", keyring, location)) {
+			logger.Fatalf("Keyring (%s) does not exist", keyring)
+		}
+
+		if err := manager.CreateKeyRing(ctx, project, location, keyring); err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	keys := []string{}
+	if listErr == nil && keyringExists {
+		if keys, listErr = manager.CryptoKeys(ctx, project, location, keyring); listErr != nil {
+			logger.Warnf("Unable to list keys: %s", listErr)
+		}
+	}
+
+	key := viper.GetString("key")
+	if key == "" && p.interactive() {
+		key, _ = utils.Choose("Google KMS Key: ", keys, p.rl)
+	}
+
+	if key == "" {
+		p.missing = append(p.missing, "key")
+		p.check()
+	}
+
+	if listErr == nil && !utils.Contains(keys, key) {
+		if !create && !p.confirm(fmt.Sprintf("Key (%s) does not exist in %s. Create it?", key, keyring)) {
+			logger.Fatalf("Key (%s) does not exist", key)
+		}
+
+		spec := crypter.KeySpec{Backend: backend}
+
+		if backend != crypter.BackendKMSAsymmetric {
+			period := p.value("rotation-period", "Key rotation period, 0 to disable (defaults to 90d): ", "90d")
+			if spec.RotationPeriod, err = utils.ParseDuration(period); err != nil {
+				logger.Fatal(err)
+			}
+		}
+
+		level := p.value("protection-level", "Key protection level SOFTWARE or HSM (defaults to SOFTWARE): ", "SOFTWARE")
+		spec.ProtectionLevel = strings.ToUpper(level)
+
+		if err := manager.CreateCryptoKey(ctx, project, location, keyring, key, spec); err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	return keyring, key
+}
+
 func init() {
 	rootCmd.AddCommand(initCmd)
 
-	// Here you will define your flags and configuration settings.
-
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// initCmd.PersistentFlags().String("foo", "", "A help for foo")
+	initCmd.Flags().String("backend", "", "The encryption backend to use (kms, kms-asymmetric, local) (default kms)")
+	initCmd.Flags().String("project", "", "The Google Cloud project of the KMS key")
+	initCmd.Flags().String("location", "", "The location of the KMS keyring (default global)")
+	initCmd.Flags().String("keyring", "", "The KMS keyring")
+	initCmd.Flags().String("key", "", "The KMS key, or the key name when using the local backend")
+	initCmd.Flags().String("key-version", "", "The KMS key version of an asymmetric key (default 1)")
+	initCmd.Flags().Bool("create", false, "Create the keyring and key if they do not exist without asking")
+	initCmd.Flags().String("rotation-period", "", "The rotation period of a created key, 0 to disable (default 90d)")
+	initCmd.Flags().String("protection-level", "", "The protection level of a created key, SOFTWARE or HSM (default SOFTWARE)")
+	initCmd.Flags().Bool("cloudbuild", false, "Encrypt secrets directly with the KMS key so gvault cloudbuild works without credentials")
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// initCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	for _, flag := range []string{"backend", "project", "location", "keyring", "key", "key-version", "create", "rotation-period", "protection-level", "cloudbuild"} {
+		viper.BindPFlag(flag, initCmd.Flags().Lookup(flag))
+	}
 }
@@ -0,0 +1,31 @@
+// Copyright © 2018 James Qualls https://github.com/sourcec0de
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/sourcec0de/gvault/vault"
+	"github.com/spf13/cobra"
+)
+
+// keysCmd represents the keys command
+var keysCmd = &cobra.Command{
+	Use:               "keys",
+	Short:             "Manage the keys that can decrypt a vault",
+	PersistentPreRunE: vault.EsureVaultLoaded(gvault),
+}
+
+func init() {
+	rootCmd.AddCommand(keysCmd)
+}
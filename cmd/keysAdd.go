@@ -0,0 +1,78 @@
+// Copyright © 2018 James Qualls https://github.com/sourcec0de
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/sourcec0de/gvault/crypter"
+	"github.com/sourcec0de/gvault/vault"
+	"github.com/spf13/cobra"
+)
+
+var keysAddLongExample = `
+Allow an additional key to decrypt the vault, e.g. a break-glass key in another project
+
+$ gvault keys add --project dr-project --location us-east1 --keyring break-glass --key gvault
+
+The vault data key is wrapped with the new key and stored alongside the existing copies.
+When decrypting, keys are tried in order until one of them succeeds.
+`
+
+// keysAddCmd represents the keys add command
+var keysAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a key that can decrypt the vault",
+	Long:  keysAddLongExample,
+	Run: func(cmd *cobra.Command, args []string) {
+		recipient := &vault.Recipient{}
+		recipient.Backend, _ = cmd.Flags().GetString("backend")
+		recipient.Project, _ = cmd.Flags().GetString("project")
+		recipient.Location, _ = cmd.Flags().GetString("location")
+		recipient.Keyring, _ = cmd.Flags().GetString("keyring")
+		recipient.Key, _ = cmd.Flags().GetString("key")
+
+		if recipient.Key == "" {
+			logger.Fatal("--key is required")
+		}
+
+		if recipient.Backend != crypter.BackendLocal && (recipient.Project == "" || recipient.Keyring == "") {
+			logger.Fatal("--project and --keyring are required for KMS keys")
+		}
+
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		if err := gvault.AddRecipient(ctx, recipient); err != nil {
+			logger.Fatal(err)
+		}
+
+		if err := gvault.Save(); err != nil {
+			logger.Fatal(err)
+		}
+
+		fmt.Printf("Added %s to %s\n", recipient.Name(), gvault.Path())
+	},
+}
+
+func init() {
+	keysCmd.AddCommand(keysAddCmd)
+
+	keysAddCmd.Flags().String("backend", crypter.BackendKMS, "The encryption backend of the key (kms, local)")
+	keysAddCmd.Flags().String("project", "", "The Google Cloud project of the key")
+	keysAddCmd.Flags().String("location", "global", "The location of the keyring")
+	keysAddCmd.Flags().String("keyring", "", "The KMS keyring of the key")
+	keysAddCmd.Flags().String("key", "", "The name of the key")
+}
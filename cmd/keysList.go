@@ -0,0 +1,37 @@
+// Copyright © 2018 James Qualls https://github.com/sourcec0de
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// keysListCmd represents the keys list command
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the keys that can decrypt the vault in the order they are tried",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Printf("%s (primary)\n", gvault.KeyName())
+		for _, recipient := range gvault.Recipients {
+			fmt.Println(recipient.Name())
+		}
+	},
+}
+
+func init() {
+	keysCmd.AddCommand(keysListCmd)
+}
@@ -0,0 +1,57 @@
+// Copyright © 2018 James Qualls https://github.com/sourcec0de
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var keysRemoveLongExample = `
+Remove a key from the vault
+
+$ gvault keys remove projects/dr-project/locations/us-east1/keyRings/break-glass/cryptoKeys/gvault
+
+Use "gvault keys list" to see the names of the keys.
+A new data key is generated and every secret re-encrypted so the removed key can not
+decrypt secrets added or changed after it was removed.
+`
+
+// keysRemoveCmd represents the keys remove command
+var keysRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove a key that can decrypt the vault",
+	Long:  keysRemoveLongExample,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		if err := gvault.RemoveRecipient(ctx, args[0]); err != nil {
+			logger.Fatal(err)
+		}
+
+		if err := gvault.Save(); err != nil {
+			logger.Fatal(err)
+		}
+
+		fmt.Printf("Removed %s from %s\n", args[0], gvault.Path())
+	},
+}
+
+func init() {
+	keysCmd.AddCommand(keysRemoveCmd)
+}
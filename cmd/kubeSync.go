@@ -67,11 +67,23 @@ var kubeSyncCmd = &cobra.Command{
 
 		secret.SetName(fmt.Sprintf("gvault-%s-%v", viper.GetString("vault"), gvault.Version))
 
-		if err := gvault.DecryptAll(); err != nil {
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		if err := gvault.DecryptAll(ctx); err != nil {
 			logger.Fatal(err)
 		}
 
-		secret.StringData = gvault.Secrets
+		// binary secrets can not be represented as string data
+		secret.StringData = map[string]string{}
+		secret.Data = map[string][]byte{}
+		for key, value := range gvault.Secrets {
+			if gvault.IsBinary(key) {
+				secret.Data[key] = []byte(value)
+			} else {
+				secret.StringData[key] = value
+			}
+		}
 
 		if _, err := client.CoreV1().Secrets(namespace).Create(secret); err != nil {
 			log.Error(errors.Wrap(err, fmt.Sprintf(secretCreationFailed, namespace)))
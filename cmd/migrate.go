@@ -0,0 +1,83 @@
+// Copyright © 2018 James Qualls https://github.com/sourcec0de
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/sourcec0de/gvault/vault"
+	"github.com/spf13/cobra"
+)
+
+var migrateLongExample = `
+Rewrite vault files in the current format
+
+$ gvault migrate
+$ gvault migrate --all
+
+Older vault files are migrated in memory whenever they are loaded. Migrating
+rewrites them so the change can be committed. No KMS access is required.
+
+Migrating does not change how secrets are encrypted, use gvault upgrade to switch
+a vault to envelope encryption.
+`
+
+// migrateCmd represents the migrate command
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Rewrite vault files in the current format",
+	Long:  migrateLongExample,
+	Run: func(cmd *cobra.Command, args []string) {
+		all, _ := cmd.Flags().GetBool("all")
+
+		vaults := []*vault.Vault{gvault}
+		if all {
+			names, err := vault.List()
+			if err != nil {
+				logger.Fatal(err)
+			}
+
+			vaults = []*vault.Vault{}
+			for _, name := range names {
+				v, err := openVault(name)
+				if err != nil {
+					logger.Fatal(err)
+				}
+				vaults = append(vaults, v)
+			}
+		} else if err := vault.EsureVaultLoaded(gvault)(cmd, args); err != nil {
+			logger.Fatal(err)
+		}
+
+		for _, v := range vaults {
+			if !v.Migrated() {
+				fmt.Printf("%s is up to date\n", v.Path())
+				continue
+			}
+
+			if err := v.Save(); err != nil {
+				logger.Fatal(err)
+			}
+
+			fmt.Printf("Migrated %s to format version %d\n", v.Path(), vault.FormatVersion)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+
+	migrateCmd.Flags().Bool("all", false, "Migrate every vault in the current directory")
+}
@@ -16,15 +16,19 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	prefixed "github.com/x-cray/logrus-prefixed-formatter"
 
 	"os"
 
+	"github.com/sourcec0de/gvault/crypter"
 	"github.com/sourcec0de/gvault/vault"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/net/context"
 )
 
 var logger *log.Logger
@@ -32,8 +36,9 @@ var gvault = vault.New(vault.Config{})
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:   "gvault",
-	Short: "Manage secrets for your Google Cloud Platorm projects",
+	Use:               "gvault",
+	Short:             "Manage secrets for your Google Cloud Platorm projects",
+	PersistentPostRun: logCrypterStats,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -56,17 +61,33 @@ func init() {
 	rootCmd.PersistentFlags().Bool("debug", false, "Enable debug statements")
 	rootCmd.PersistentFlags().StringP("vault", "v", "", "The name of the vault you want to use (default to main)")
 	viper.BindPFlag("vault", rootCmd.PersistentFlags().Lookup("vault"))
+	rootCmd.PersistentFlags().Int("concurrency", vault.DefaultConcurrency, "The maximum number of parallel encrypt / decrypt requests")
+	rootCmd.PersistentFlags().Int("kms-max-retries", crypter.DefaultMaxRetries, "The number of times a failed KMS request is retried, 0 disables retries")
+	rootCmd.PersistentFlags().Float64("kms-rate-limit", 0, "The maximum number of KMS requests per second (default unlimited)")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "Abort encrypting or decrypting after this duration, e.g. 30s (default no timeout)")
 	viper.BindPFlag("debug", rootCmd.PersistentFlags().Lookup("debug"))
+	viper.BindPFlag("concurrency", rootCmd.PersistentFlags().Lookup("concurrency"))
+	viper.BindPFlag("timeout", rootCmd.PersistentFlags().Lookup("timeout"))
+	viper.BindPFlag("kms-max-retries", rootCmd.PersistentFlags().Lookup("kms-max-retries"))
+	viper.BindPFlag("kms-rate-limit", rootCmd.PersistentFlags().Lookup("kms-rate-limit"))
+	rootCmd.PersistentFlags().String("credentials-file", "", "Path to a Google credentials JSON file (default application default credentials)")
+	rootCmd.PersistentFlags().String("impersonate-service-account", "", "Email of a service account to impersonate when calling KMS")
+	viper.BindPFlag("credentials-file", rootCmd.PersistentFlags().Lookup("credentials-file"))
+	viper.BindPFlag("impersonate-service-account", rootCmd.PersistentFlags().Lookup("impersonate-service-account"))
+	rootCmd.PersistentFlags().String("kms-endpoint", "", "Base URL of an alternative Cloud KMS endpoint, e.g. an emulator or private service connect address")
+	rootCmd.PersistentFlags().Bool("kms-no-auth", false, "Send unauthenticated KMS requests, e.g. to a local emulator")
+	viper.BindPFlag("kms-endpoint", rootCmd.PersistentFlags().Lookup("kms-endpoint"))
+	viper.BindPFlag("kms-no-auth", rootCmd.PersistentFlags().Lookup("kms-no-auth"))
 	viper.SetDefault("vault", "main")
 	viper.SetEnvPrefix("GVAULT")
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	// project config, e.g. .gvault.yaml next to the vaults
+	viper.SetConfigName(".gvault")
+	viper.AddConfigPath(".")
 
 	// init logger
 	logger = log.New()
 	logger.Formatter = new(prefixed.TextFormatter)
-
-	if initCrypterErr := gvault.InitCrypter(); initCrypterErr != nil {
-		log.Fatal(initCrypterErr)
-	}
 }
 
 func initConfig() {
@@ -83,6 +104,15 @@ func initConfig() {
 func initVault() {
 
 	gvault.Name = viper.GetString("vault")
+	configureVault(gvault)
+
+	if gvault.Options.NoAuth && gvault.Options.Endpoint == "" {
+		logger.Fatal("--kms-no-auth requires --kms-endpoint")
+	}
+
+	if gvault.Options.Endpoint != "" {
+		logger.Debugf("Using KMS endpoint %s", gvault.Options.Endpoint)
+	}
 
 	if exists, _ := gvault.Exists(); exists {
 		if loadErr := gvault.Load(); loadErr != nil {
@@ -91,3 +121,76 @@ func initVault() {
 		logger.Debugf("Using vault (%s)", gvault.Path())
 	}
 }
+
+// configureVault applies the global flags to a vault
+func configureVault(v *vault.Vault) {
+	v.Concurrency = viper.GetInt("concurrency")
+	v.Options = crypter.Options{
+		MaxRetries: viper.GetInt("kms-max-retries"),
+		RateLimit:  viper.GetFloat64("kms-rate-limit"),
+		OnRetry: func(attempt int, delay time.Duration, err error) {
+			logger.Debugf("Retrying KMS request (attempt %d) in %s: %s", attempt, delay, err)
+		},
+		CredentialsFile:           credentialSetting(v.Name, "credentials-file"),
+		ImpersonateServiceAccount: credentialSetting(v.Name, "impersonate-service-account"),
+		Endpoint:                  viper.GetString("kms-endpoint"),
+		NoAuth:                    viper.GetBool("kms-no-auth"),
+	}
+	v.DefaultOptions = crypter.Options{
+		CredentialsFile:           viper.GetString("credentials-file"),
+		ImpersonateServiceAccount: viper.GetString("impersonate-service-account"),
+	}
+}
+
+// openVault loads another vault than the one selected with --vault
+func openVault(name string) (*vault.Vault, error) {
+	v := gvault
+	if name != gvault.Name {
+		v = vault.New(vault.Config{Name: name})
+		configureVault(v)
+	}
+
+	exists, err := v.Exists()
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, fmt.Errorf("The vault (%s) doesnt exist. You must first initialize it with `gvault init --vault %s`", name, name)
+	}
+
+	if v == gvault {
+		return v, nil
+	}
+
+	if err := v.Load(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// credentialSetting resolves an identity setting chosen for a vault, either with a flag
+// or in the vaults section of the project config. When empty the setting stored in the
+// vault file is used, followed by GVAULT_* environment variables and top level project config
+func credentialSetting(vaultName, key string) string {
+	if flag := rootCmd.PersistentFlags().Lookup(key); flag != nil && flag.Changed {
+		return flag.Value.String()
+	}
+
+	return viper.GetString(fmt.Sprintf("vaults.%s.%s", vaultName, key))
+}
+
+// commandContext returns the context used for crypter calls honoring the --timeout flag
+func commandContext() (context.Context, context.CancelFunc) {
+	if timeout := viper.GetDuration("timeout"); timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// logCrypterStats reports how many crypter requests had to be retried
+func logCrypterStats(cmd *cobra.Command, args []string) {
+	if stats, ok := gvault.Crypter.(crypter.Stats); ok {
+		logger.Debugf("%d KMS request(s) were retried", stats.Retries())
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright © 2018 James Qualls https://github.com/sourcec0de
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/sourcec0de/gvault/vault"
+	"github.com/spf13/cobra"
+)
+
+var rotateLongExample = `
+Re-encrypt every secret in the vault with the current primary key version
+
+$ gvault rotate
+
+Move the vault to a different key and keyring
+
+$ gvault rotate --keyring other-keyring --key other-key
+
+Move an asymmetric vault to a new primary key version, fetching its public key
+
+$ gvault rotate --key-version 2
+
+Every secret is decrypted and re-encrypted before anything is written. Rotating also
+binds each secret to its vault and name, upgrading vaults created by older versions.
+The vault file is only replaced if all secrets were re-encrypted successfully,
+after which older key versions can safely be disabled or destroyed.
+`
+
+// rotateCmd represents the rotate command
+var rotateCmd = &cobra.Command{
+	Use:     "rotate",
+	Short:   "Re-encrypt the whole vault with the current or a new key",
+	Long:    rotateLongExample,
+	PreRunE: vault.EsureVaultLoaded(gvault),
+	Run: func(cmd *cobra.Command, args []string) {
+		keyring, _ := cmd.Flags().GetString("keyring")
+		key, _ := cmd.Flags().GetString("key")
+		keyVersion, _ := cmd.Flags().GetString("key-version")
+
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		if err := gvault.Rotate(ctx, keyring, key, keyVersion); err != nil {
+			logger.Fatal(err)
+		}
+
+		if err := gvault.Save(); err != nil {
+			logger.Fatal(err)
+		}
+
+		fmt.Printf("Rotated %d secrets in %s using %s\n", len(gvault.Secrets), gvault.Path(), gvault.KmsKeyName())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rotateCmd)
+
+	rotateCmd.Flags().String("keyring", "", "Move the vault to this KMS keyring")
+	rotateCmd.Flags().String("key", "", "Move the vault to this KMS key")
+	rotateCmd.Flags().String("key-version", "", "Move an asymmetric vault to this KMS key version")
+}
@@ -16,18 +16,39 @@ package cmd
 
 import (
 	"fmt"
-	"io/ioutil"
 	"strings"
 
+	"github.com/chzyer/readline"
+	"github.com/sourcec0de/gvault/utils"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+var secretsAddLongExample = `
+Add secrets to the vault
+
+$ gvault secrets add API_KEY=value OTHER_KEY=value
+
+Values are split from the key on the first = and may be quoted. Double quoted values
+support the escapes \n \r \t \\ \" and \'. Unquoted values starting with @ are read from
+a file and - reads the value from stdin:
+
+$ gvault secrets add 'TOKEN="line one\nline two"'
+$ gvault secrets add TLS_KEY=@tls.key
+$ vault-export | gvault secrets add API_KEY=-
+
+Omit the value to keep it out of your shell history. It is prompted for without
+echo, or read from stdin when stdin is not a terminal:
+
+$ gvault secrets add API_KEY --confirm
+$ pbpaste | gvault secrets add API_KEY
+`
+
 // secretsAddCmd represents the create command
 var secretsAddCmd = &cobra.Command{
-	Use:   "add",
+	Use:   "add KEY[=VALUE]...",
 	Short: "Add a new secret to the vault",
-	Long:  ``,
+	Long:  secretsAddLongExample,
 	Args: func(cmd *cobra.Command, args []string) error {
 		file := viper.GetString("file")
 		name := viper.GetString("name")
@@ -40,6 +61,28 @@ var secretsAddCmd = &cobra.Command{
 		if len(args) < 1 && !usingFile {
 			return fmt.Errorf("must supply at least one KEY=VALUE pair")
 		}
+
+		if usingFile {
+			if err := utils.ValidateEnvName(name); err != nil {
+				return err
+			}
+		}
+
+		fromStdin := 0
+		for _, arg := range args {
+			pair, prompt, err := parseSecretArg(arg)
+			if err != nil {
+				return err
+			}
+
+			if pair.ReadsStdin() || (prompt && !utils.IsTerminal()) {
+				fromStdin++
+			}
+		}
+
+		if fromStdin > 1 {
+			return fmt.Errorf("only one value can be read from stdin")
+		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
@@ -47,27 +90,58 @@ var secretsAddCmd = &cobra.Command{
 		file := viper.GetString("file")
 		name := viper.GetString("name")
 
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		added := []string{}
+
 		if file != "" && name != "" {
-			bytes, err := ioutil.ReadFile(name)
+			if err := gvault.SetFileSecret(ctx, name, file); err != nil {
+				logger.Fatal(err)
+			}
+			added = append(added, name)
+		}
 
+		for _, arg := range args {
+			pair, prompt, err := parseSecretArg(arg)
 			if err != nil {
 				logger.Fatal(err)
 			}
 
-			if err := gvault.SetSecret(name, string(bytes)); err != nil {
-				logger.Fatal(err)
+			var value string
+			if prompt {
+				value, err = readSecretValue(cmd, pair.Key)
+			} else {
+				value, err = pair.Resolve()
 			}
-		}
 
-		for _, arg := range args {
-			pair := strings.Split(arg, "=")
-			if len(pair) != 2 {
-				logger.Fatalf("%s is not a valid KEY=VALUE pair", arg)
+			if err != nil {
+				logger.Fatal(err)
 			}
 
-			if err := gvault.SetSecret(pair[0], pair[1]); err != nil {
+			if err := gvault.SetSecret(ctx, pair.Key, value); err != nil {
 				logger.Fatal(err)
 			}
+			added = append(added, pair.Key)
+		}
+
+		description, _ := cmd.Flags().GetString("description")
+		owner, _ := cmd.Flags().GetString("owner")
+		tags, _ := cmd.Flags().GetStringSlice("tag")
+
+		for _, key := range added {
+			metadata := gvault.SecretMetadata(key)
+			if cmd.Flags().Changed("description") {
+				metadata.Description = description
+			}
+			if cmd.Flags().Changed("owner") {
+				metadata.Owner = owner
+			}
+			for _, tag := range tags {
+				if !utils.Contains(metadata.Tags, tag) {
+					metadata.Tags = append(metadata.Tags, tag)
+				}
+			}
 		}
 
 		if err := gvault.Save(); err != nil {
@@ -76,6 +150,48 @@ var secretsAddCmd = &cobra.Command{
 	},
 }
 
+// parseSecretArg parses a KEY=VALUE argument, a KEY without a value has to be prompted for
+func parseSecretArg(arg string) (utils.Pair, bool, error) {
+	if !strings.Contains(arg, "=") {
+		return utils.Pair{Key: arg}, true, utils.ValidateEnvName(arg)
+	}
+
+	pair, err := utils.ParsePair(arg)
+	return pair, false, err
+}
+
+// readSecretValue prompts for the value of a secret without echo
+// or reads it from stdin when stdin is not a terminal
+func readSecretValue(cmd *cobra.Command, name string) (string, error) {
+	if !utils.IsTerminal() {
+		return utils.ReadStdinValue(), nil
+	}
+
+	rl, err := readline.New("")
+	if err != nil {
+		return "", err
+	}
+	defer rl.Close()
+
+	value, err := utils.AskPassword(fmt.Sprintf("Value for %s: ", name), rl)
+	if err != nil {
+		return "", err
+	}
+
+	if confirm, _ := cmd.Flags().GetBool("confirm"); confirm {
+		again, err := utils.AskPassword(fmt.Sprintf("Confirm value for %s: ", name), rl)
+		if err != nil {
+			return "", err
+		}
+
+		if again != value {
+			return "", fmt.Errorf("the values for %s do not match", name)
+		}
+	}
+
+	return value, nil
+}
+
 func init() {
 	secretsCmd.AddCommand(secretsAddCmd)
 
@@ -83,6 +199,10 @@ func init() {
 	secretsAddCmd.Flags().String("name", "", "The name of the secret being added to the vault (only works with --file)")
 	viper.BindPFlag("file", secretsAddCmd.Flags().Lookup("file"))
 	viper.BindPFlag("name", secretsAddCmd.Flags().Lookup("name"))
+	secretsAddCmd.Flags().String("description", "", "A description of what the secret is used for")
+	secretsAddCmd.Flags().String("owner", "", "The team or person responsible for the secret")
+	secretsAddCmd.Flags().Bool("confirm", false, "Ask for prompted values twice")
+	secretsAddCmd.Flags().StringSlice("tag", []string{}, "Tag the secret, may be repeated")
 
 	// Here you will define your flags and configuration settings.
 
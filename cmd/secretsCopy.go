@@ -0,0 +1,125 @@
+// Copyright © 2018 James Qualls https://github.com/sourcec0de
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/sourcec0de/gvault/utils"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+var secretsCopyLongExample = `
+Copy a secret to another vault or name
+
+$ gvault secrets copy MYSQL_PASSWORD --to-vault staging
+$ gvault secrets copy MYSQL_PASSWORD MYSQL_REPLICA_PASSWORD
+
+The secret is decrypted with the key of the source vault and encrypted with the
+key of the destination vault, its metadata is kept.
+`
+
+// secretsCopyCmd represents the secrets copy command
+var secretsCopyCmd = &cobra.Command{
+	Use:   "copy NAME [NEW_NAME]",
+	Short: "Copy a secret to another vault or name",
+	Long:  secretsCopyLongExample,
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runSecretTransfer(cmd, args, false)
+	},
+}
+
+// runSecretTransfer runs secrets copy and secrets move
+func runSecretTransfer(cmd *cobra.Command, args []string, remove bool) {
+	toVault, _ := cmd.Flags().GetString("to-vault")
+	force, _ := cmd.Flags().GetBool("force")
+
+	if toVault == "" {
+		toVault = gvault.Name
+	}
+
+	newKey := args[0]
+	if len(args) == 2 {
+		newKey = args[1]
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if err := transferSecret(ctx, args[0], toVault, newKey, force, remove); err != nil {
+		logger.Fatal(err)
+	}
+
+	verb := "Copied"
+	if remove {
+		verb = "Moved"
+	}
+	fmt.Printf("%s %s to %s in %s\n", verb, args[0], newKey, toVault)
+}
+
+// transferSecret copies a secret of the current vault to newKey in the vault toVault
+// and removes the original when remove is set. The destination is saved first so
+// a failure never loses the secret
+func transferSecret(ctx context.Context, key, toVault, newKey string, force, remove bool) error {
+	if _, ok := gvault.Secrets[key]; !ok {
+		return fmt.Errorf("No secret by the name %s", key)
+	}
+
+	if err := utils.ValidateEnvName(newKey); err != nil {
+		return err
+	}
+
+	dst, err := openVault(toVault)
+	if err != nil {
+		return err
+	}
+
+	if dst == gvault && key == newKey {
+		return errors.New("the source and destination are the same")
+	}
+
+	if _, exists := dst.Secrets[newKey]; exists && !force {
+		return fmt.Errorf("%s already exists in %s, use --force to overwrite it", newKey, dst.Name)
+	}
+
+	if err := gvault.CopySecret(ctx, key, dst, newKey); err != nil {
+		return err
+	}
+
+	if dst != gvault {
+		if err := dst.Save(); err != nil {
+			return err
+		}
+	}
+
+	if remove {
+		gvault.RemoveSecret(key)
+	}
+
+	if remove || dst == gvault {
+		return gvault.Save()
+	}
+	return nil
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsCopyCmd)
+
+	secretsCopyCmd.Flags().String("to-vault", "", "The vault to copy the secret to (defaults to the current vault)")
+	secretsCopyCmd.Flags().Bool("force", false, "Overwrite an existing secret in the destination")
+}
@@ -28,7 +28,10 @@ var secretsExportCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 
 		if viper.GetBool("decrypt") {
-			if err := gvault.DecryptAll(); err != nil {
+			ctx, cancel := commandContext()
+			defer cancel()
+
+			if err := gvault.DecryptAll(ctx); err != nil {
 				logger.Fatal(err)
 			}
 		}
@@ -15,7 +15,7 @@
 package cmd
 
 import (
-	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 )
@@ -24,20 +24,62 @@ import (
 var secretsGetCmd = &cobra.Command{
 	Use:   "get",
 	Short: "Retrieve and decrypt a secret from the vault",
+	Long:  "Prints the secret or writes its exact bytes to a file with --out",
 	Args:  cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		secret, err := gvault.GetSecret(args[0])
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		secret, err := gvault.GetSecretBytes(ctx, args[0])
 		if err != nil {
 			logger.Fatal(err)
 		}
 
-		fmt.Print(secret)
+		if out, _ := cmd.Flags().GetString("out"); out != "" {
+			if err := writeSecretFile(out, secret, secretFileMode(args[0])); err != nil {
+				logger.Fatal(err)
+			}
+			return
+		}
+
+		os.Stdout.Write(secret)
 	},
 }
 
+// secretFileMode the permissions a secret is written with, the original permissions
+// of file secrets are kept for the owner only and the owner can always read and write
+func secretFileMode(key string) os.FileMode {
+	if metadata, ok := gvault.Metadata[key]; ok && metadata.Mode != 0 {
+		return (metadata.Mode & 0700) | 0600
+	}
+	return 0600
+}
+
+// writeSecretFile writes data to path, restricting the permissions of existing files as well
+func writeSecretFile(path string, data []byte, perm os.FileMode) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if err := file.Chmod(perm); err != nil {
+		file.Close()
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
 func init() {
 	secretsCmd.AddCommand(secretsGetCmd)
 
+	secretsGetCmd.Flags().String("out", "", "Write the secret to this file instead of stdout")
+
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
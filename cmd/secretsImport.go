@@ -29,9 +29,17 @@ var secretsImportCmd = &cobra.Command{
 		if err != nil {
 			logger.Fatal(err)
 		}
-		encryptedEnvMap, _ := gvault.EncryptEnvMap(envMap)
-		gvault.MergeEncryptedEnvMap(encryptedEnvMap)
-		gvault.Save()
+
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		if err := gvault.ImportEnvMap(ctx, envMap); err != nil {
+			logger.Fatal(err)
+		}
+
+		if err := gvault.Save(); err != nil {
+			logger.Fatal(err)
+		}
 	},
 }
 
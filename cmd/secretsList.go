@@ -0,0 +1,147 @@
+// Copyright © 2018 James Qualls https://github.com/sourcec0de
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sourcec0de/gvault/utils"
+	"github.com/sourcec0de/gvault/vault"
+	"github.com/spf13/cobra"
+)
+
+var secretsListLongExample = `
+List secret names and metadata without decrypting anything
+
+$ gvault secrets list
+$ gvault secrets list 'MYSQL_*'
+$ gvault secrets list --regex '^(MYSQL|REDIS)_' --tag prod
+$ gvault secrets list --output json
+`
+
+// secretListing a secret as printed by secrets list
+type secretListing struct {
+	Name string `json:"name"`
+	*vault.SecretMetadata
+}
+
+// secretsListCmd represents the secrets list command
+var secretsListCmd = &cobra.Command{
+	Use:   "list [PATTERN]",
+	Short: "List the secrets in the vault with their metadata",
+	Long:  secretsListLongExample,
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		useRegex, _ := cmd.Flags().GetBool("regex")
+		tags, _ := cmd.Flags().GetStringSlice("tag")
+
+		match := func(name string) (bool, error) { return true, nil }
+		if len(args) == 1 {
+			if useRegex {
+				re, err := regexp.Compile(args[0])
+				if err != nil {
+					logger.Fatal(err)
+				}
+				match = func(name string) (bool, error) { return re.MatchString(name), nil }
+			} else {
+				match = func(name string) (bool, error) { return filepath.Match(args[0], name) }
+			}
+		}
+
+		listings := []secretListing{}
+		for _, name := range gvault.SecretNames() {
+			matched, err := match(name)
+			if err != nil {
+				logger.Fatal(err)
+			}
+
+			metadata := gvault.Metadata[name]
+			if metadata == nil {
+				metadata = &vault.SecretMetadata{}
+			}
+
+			if matched && hasTags(metadata, tags) {
+				listings = append(listings, secretListing{Name: name, SecretMetadata: metadata})
+			}
+		}
+
+		switch output {
+		case "json":
+			bytes, err := json.MarshalIndent(listings, "", "  ")
+			if err != nil {
+				logger.Fatal(err)
+			}
+			fmt.Println(string(bytes))
+		case "table":
+			printSecretListings(listings)
+		default:
+			logger.Fatalf("%s is not a supported output format", output)
+		}
+	},
+}
+
+// hasTags reports whether metadata is tagged with all tags
+func hasTags(metadata *vault.SecretMetadata, tags []string) bool {
+	for _, tag := range tags {
+		if !utils.Contains(metadata.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+func printSecretListings(listings []secretListing) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSIZE\tUPDATED\tUPDATED BY\tOWNER\tTAGS\tDESCRIPTION")
+
+	for _, listing := range listings {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			listing.Name,
+			orDash(listing.Size != nil, func() string { return strconv.Itoa(*listing.Size) }),
+			orDash(!listing.UpdatedAt.IsZero(), func() string { return listing.UpdatedAt.Local().Format(time.RFC3339) }),
+			orDash(listing.UpdatedBy != "", func() string { return listing.UpdatedBy }),
+			orDash(listing.Owner != "", func() string { return listing.Owner }),
+			orDash(len(listing.Tags) > 0, func() string { return strings.Join(listing.Tags, ",") }),
+			listing.Description,
+		)
+	}
+
+	w.Flush()
+}
+
+// orDash returns the value when it is known and a dash otherwise
+func orDash(known bool, value func() string) string {
+	if !known {
+		return "-"
+	}
+	return value()
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsListCmd)
+
+	secretsListCmd.Flags().StringP("output", "o", "table", "The output format (table, json)")
+	secretsListCmd.Flags().Bool("regex", false, "Treat PATTERN as a regular expression instead of a glob")
+	secretsListCmd.Flags().StringSlice("tag", []string{}, "Only list secrets with this tag, may be repeated")
+}
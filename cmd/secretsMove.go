@@ -0,0 +1,46 @@
+// Copyright © 2018 James Qualls https://github.com/sourcec0de
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var secretsMoveLongExample = `
+Move a secret to another vault
+
+$ gvault secrets move MYSQL_PASSWORD --to-vault staging
+
+The secret is decrypted with the key of the source vault, encrypted with the key of
+the destination vault and removed from the source once the destination was saved.
+`
+
+// secretsMoveCmd represents the secrets move command
+var secretsMoveCmd = &cobra.Command{
+	Use:   "move NAME [NEW_NAME]",
+	Short: "Move a secret to another vault",
+	Long:  secretsMoveLongExample,
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runSecretTransfer(cmd, args, true)
+	},
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsMoveCmd)
+
+	secretsMoveCmd.Flags().String("to-vault", "", "The vault to move the secret to (defaults to the current vault)")
+	secretsMoveCmd.Flags().Bool("force", false, "Overwrite an existing secret in the destination")
+}
@@ -0,0 +1,47 @@
+// Copyright © 2018 James Qualls https://github.com/sourcec0de
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// secretsRenameCmd represents the secrets rename command
+var secretsRenameCmd = &cobra.Command{
+	Use:   "rename OLD_NAME NEW_NAME",
+	Short: "Rename a secret in the vault",
+	Long:  "Renames a secret, it is encrypted again as the name is part of its authenticated data",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		force, _ := cmd.Flags().GetBool("force")
+
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		if err := transferSecret(ctx, args[0], gvault.Name, args[1], force, true); err != nil {
+			logger.Fatal(err)
+		}
+
+		fmt.Printf("Renamed %s to %s\n", args[0], args[1])
+	},
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsRenameCmd)
+
+	secretsRenameCmd.Flags().Bool("force", false, "Overwrite an existing secret with the new name")
+}
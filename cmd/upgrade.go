@@ -0,0 +1,61 @@
+// Copyright © 2018 James Qualls https://github.com/sourcec0de
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/sourcec0de/gvault/vault"
+	"github.com/spf13/cobra"
+)
+
+var upgradeLongExample = `
+Upgrade an existing vault to envelope encryption
+
+$ gvault upgrade
+
+A new data key is generated and wrapped with your KMS key. Every secret is decrypted
+and re-encrypted locally with the data key, so exporting or syncing the vault only
+requires a single KMS call and secrets are no longer limited to 64KiB.
+
+Upgrading changes how secrets are encrypted and requires KMS access. To only rewrite
+a vault file in the current format use gvault migrate.
+`
+
+// upgradeCmd represents the upgrade command
+var upgradeCmd = &cobra.Command{
+	Use:     "upgrade",
+	Short:   "Upgrade a vault to envelope encryption with a per-vault data key",
+	Long:    upgradeLongExample,
+	PreRunE: vault.EsureVaultLoaded(gvault),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		if err := gvault.EnableEnvelopeEncryption(ctx); err != nil {
+			logger.Fatal(err)
+		}
+
+		if err := gvault.Save(); err != nil {
+			logger.Fatal(err)
+		}
+
+		fmt.Printf("Upgraded %s\n", gvault.Path())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(upgradeCmd)
+}
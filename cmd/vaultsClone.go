@@ -0,0 +1,133 @@
+// Copyright © 2018 James Qualls https://github.com/sourcec0de
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/sourcec0de/gvault/crypter"
+	"github.com/sourcec0de/gvault/vault"
+	"github.com/spf13/cobra"
+)
+
+var vaultsCloneLongExample = `
+Clone a vault, optionally encrypting the copy with another key
+
+$ gvault vaults clone main staging
+$ gvault vaults clone main staging --project staging-project --keyring gvault --key staging
+
+Key settings that are not supplied are taken from the source vault. The key version
+is only kept when the same key is used and defaults to 1 for other asymmetric keys.
+Every secret is decrypted with the source key and encrypted with the key of the new
+vault, secret metadata is kept. Additional keys of the source vault are not copied.
+
+Clone a vault with --cloudbuild to export it with gvault cloudbuild:
+
+$ gvault vaults clone main main-cloudbuild --cloudbuild
+`
+
+// vaultsCloneCmd represents the vaults clone command
+var vaultsCloneCmd = &cobra.Command{
+	Use:   "clone SOURCE DESTINATION",
+	Short: "Clone a vault into a new vault",
+	Long:  vaultsCloneLongExample,
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		src, err := openVault(args[0])
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		dst := vault.New(vault.Config{
+			Name:     args[1],
+			Backend:  cloneSetting(cmd, "backend", src.Backend),
+			Project:  cloneSetting(cmd, "project", src.Project),
+			Location: cloneSetting(cmd, "location", src.Location),
+			Keyring:  cloneSetting(cmd, "keyring", src.Keyring),
+			Key:      cloneSetting(cmd, "key", src.Key),
+		})
+		dst.KeyVersion = cloneKeyVersion(cmd, src, dst)
+		dst.CloudBuild = src.CloudBuild
+		if cmd.Flags().Changed("cloudbuild") {
+			dst.CloudBuild, _ = cmd.Flags().GetBool("cloudbuild")
+		}
+		dst.CredentialsFile = src.CredentialsFile
+		dst.ImpersonateServiceAccount = src.ImpersonateServiceAccount
+		configureVault(dst)
+
+		if exists, err := dst.Exists(); exists || err != nil {
+			if err != nil {
+				logger.Fatal(err)
+			}
+			logger.Fatalf("A gvault with the name (%s) already exists: %s", dst.Name, dst.Path())
+		}
+
+		ctx, cancel := commandContext()
+		defer cancel()
+
+		if err := setupVaultKey(ctx, dst); err != nil {
+			logger.Fatal(err)
+		}
+
+		if err := src.CopyAll(ctx, dst); err != nil {
+			logger.Fatal(err)
+		}
+
+		if err := dst.Save(); err != nil {
+			logger.Fatal(err)
+		}
+
+		fmt.Printf("Cloned %s to %s\n", src.Path(), dst.Path())
+	},
+}
+
+// cloneSetting returns the flag value if it was supplied and the source setting otherwise
+func cloneSetting(cmd *cobra.Command, flag, source string) string {
+	if !cmd.Flags().Changed(flag) {
+		return source
+	}
+	value, _ := cmd.Flags().GetString(flag)
+	return value
+}
+
+// cloneKeyVersion the key version of the new vault. The source version is only kept
+// when the same key is used, otherwise it defaults like init does
+func cloneKeyVersion(cmd *cobra.Command, src, dst *vault.Vault) string {
+	if cmd.Flags().Changed("key-version") {
+		value, _ := cmd.Flags().GetString("key-version")
+		return value
+	}
+
+	if dst.KmsKeyName() == src.KmsKeyName() && dst.Backend == src.Backend {
+		return src.KeyVersion
+	}
+
+	if dst.Backend == crypter.BackendKMSAsymmetric {
+		return "1"
+	}
+	return ""
+}
+
+func init() {
+	vaultsCmd.AddCommand(vaultsCloneCmd)
+
+	vaultsCloneCmd.Flags().String("backend", "", "The encryption backend of the new vault (kms, kms-asymmetric, local)")
+	vaultsCloneCmd.Flags().String("project", "", "The Google Cloud project of the new key")
+	vaultsCloneCmd.Flags().String("location", "", "The location of the new keyring")
+	vaultsCloneCmd.Flags().String("keyring", "", "The KMS keyring of the new key")
+	vaultsCloneCmd.Flags().String("key", "", "The new KMS key, or the key name when using the local backend")
+	vaultsCloneCmd.Flags().String("key-version", "", "The KMS key version of an asymmetric key (default 1 for a new key)")
+	vaultsCloneCmd.Flags().Bool("cloudbuild", false, "Encrypt secrets directly with the KMS key so gvault cloudbuild works without credentials (default from the source vault)")
+}
@@ -0,0 +1,67 @@
+// Copyright © 2018 James Qualls https://github.com/sourcec0de
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/chzyer/readline"
+	"github.com/sourcec0de/gvault/utils"
+	"github.com/spf13/cobra"
+)
+
+// vaultsDeleteCmd represents the vaults delete command
+var vaultsDeleteCmd = &cobra.Command{
+	Use:   "delete NAME",
+	Short: "Delete a vault file",
+	Long:  "Deletes the vault file after confirmation. The keys of the vault are not deleted",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := openVault(args[0])
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+			if !utils.IsTerminal() {
+				logger.Fatal("--yes is required to delete a vault when not running in a terminal")
+			}
+
+			rl, err := readline.New("")
+			if err != nil {
+				logger.Fatal(err)
+			}
+
+			confirmed := utils.Confirm(fmt.Sprintf("Delete %s with %d secret(s)?", v.Path(), len(v.Secrets)), rl)
+			rl.Close()
+
+			if !confirmed {
+				return
+			}
+		}
+
+		if err := v.Delete(); err != nil {
+			logger.Fatal(err)
+		}
+
+		fmt.Printf("Deleted %s\n", v.Path())
+	},
+}
+
+func init() {
+	vaultsCmd.AddCommand(vaultsDeleteCmd)
+
+	vaultsDeleteCmd.Flags().Bool("yes", false, "Delete without asking for confirmation")
+}
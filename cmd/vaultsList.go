@@ -0,0 +1,56 @@
+// Copyright © 2018 James Qualls https://github.com/sourcec0de
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/sourcec0de/gvault/vault"
+	"github.com/spf13/cobra"
+)
+
+// vaultsListCmd represents the vaults list command
+var vaultsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the vaults in the current directory",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		names, err := vault.List()
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tKEY\tSECRETS\tVERSION")
+
+		for _, name := range names {
+			v, err := openVault(name)
+			if err != nil {
+				fmt.Fprintf(w, "%s\t%s\t-\t-\n", name, err)
+				continue
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%d\t%v\n", name, v.KeyName(), len(v.Secrets), v.Version)
+		}
+
+		w.Flush()
+	},
+}
+
+func init() {
+	vaultsCmd.AddCommand(vaultsListCmd)
+}
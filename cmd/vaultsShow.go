@@ -0,0 +1,75 @@
+// Copyright © 2018 James Qualls https://github.com/sourcec0de
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// vaultsShowCmd represents the vaults show command
+var vaultsShowCmd = &cobra.Command{
+	Use:   "show [NAME]",
+	Short: "Show the settings of a vault (defaults to the current vault)",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := gvault.Name
+		if len(args) == 1 {
+			name = args[0]
+		}
+
+		v, err := openVault(name)
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		backend := v.Backend
+		if backend == "" {
+			backend = "kms"
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "Name:\t%s\n", v.Name)
+		fmt.Fprintf(w, "Path:\t%s\n", v.Path())
+		fmt.Fprintf(w, "Format version:\t%d\n", v.FormatVersion)
+		fmt.Fprintf(w, "Backend:\t%s\n", backend)
+		fmt.Fprintf(w, "Key:\t%s\n", v.KeyName())
+		if v.KeyVersion != "" {
+			fmt.Fprintf(w, "Key version:\t%s\n", v.KeyVersion)
+		}
+		for _, recipient := range v.Recipients {
+			fmt.Fprintf(w, "Additional key:\t%s\n", recipient.Name())
+		}
+		fmt.Fprintf(w, "Envelope encryption:\t%t\n", v.DataKey != "")
+		fmt.Fprintf(w, "Tamper protection:\t%t\n", v.AAD)
+		fmt.Fprintf(w, "Cloud Build:\t%t\n", v.CloudBuild)
+		if v.CredentialsFile != "" {
+			fmt.Fprintf(w, "Credentials file:\t%s\n", v.CredentialsFile)
+		}
+		if v.ImpersonateServiceAccount != "" {
+			fmt.Fprintf(w, "Impersonate:\t%s\n", v.ImpersonateServiceAccount)
+		}
+		fmt.Fprintf(w, "Secrets:\t%d\n", len(v.Secrets))
+		fmt.Fprintf(w, "Version:\t%v\n", v.Version)
+		w.Flush()
+	},
+}
+
+func init() {
+	vaultsCmd.AddCommand(vaultsShowCmd)
+}
@@ -0,0 +1,278 @@
+package crypter
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	cloudkms "google.golang.org/api/cloudkms/v1"
+)
+
+// BackendKMSAsymmetric is the name of the Google Cloud KMS asymmetric decryption backend
+const BackendKMSAsymmetric = "kms-asymmetric"
+
+// cipher text format markers for asymmetric encryption
+const (
+	// asymmetricOAEP the plain text is encrypted directly with RSA-OAEP
+	asymmetricOAEP byte = 1
+	// asymmetricHybrid the plain text is encrypted with AES-GCM and the AES key with RSA-OAEP
+	asymmetricHybrid byte = 2
+)
+
+func init() {
+	Register(BackendKMSAsymmetric, func(config Config) (Crypter, error) {
+		return NewAsymmetricCrypter(config), nil
+	})
+}
+
+// AsymmetricCrypter encrypts secrets locally with the public key of a Cloud KMS
+// asymmetric decryption key and decrypts them with KMS AsymmetricDecrypt.
+// Encrypting only requires the public key cached in the vault, so users without
+// decrypt permissions on the key are still able to add secrets.
+// Keys must use one of the RSA_DECRYPT_OAEP_*_SHA256 algorithms
+type AsymmetricCrypter struct {
+	Project    *string
+	Location   *string
+	Keyring    *string
+	Key        *string
+	KeyVersion *string
+	PublicKey  *string
+	options    Options
+	once       sync.Once
+	kms        *cloudkms.Service
+	err        error
+	*retrier
+}
+
+// NewAsymmetricCrypter creates a new AsymmetricCrypter instance
+// the KMS client is only created once something needs to be decrypted
+func NewAsymmetricCrypter(config Config) *AsymmetricCrypter {
+	return &AsymmetricCrypter{
+		Project:    config.Project,
+		Location:   config.Location,
+		Keyring:    config.Keyring,
+		Key:        config.Key,
+		KeyVersion: config.KeyVersion,
+		PublicKey:  config.PublicKey,
+		options:    config.Options,
+		retrier:    newRetrier(config.Options),
+	}
+}
+
+// KmsKeyVersionName name of the kms key version
+func (c *AsymmetricCrypter) KmsKeyVersionName() string {
+	return KmsKeyVersionName(*c.Project, *c.Location, *c.Keyring, *c.Key, *c.KeyVersion)
+}
+
+func (c *AsymmetricCrypter) service() (*cloudkms.Service, error) {
+	c.once.Do(func() {
+		c.kms, c.err = newKMSService(context.Background(), c.options)
+	})
+	return c.kms, c.err
+}
+
+// keyVersionPollInterval how often the state of a key version being generated is checked
+const keyVersionPollInterval = time.Second
+
+// FetchPublicKey retrieves the PEM encoded public key of the key version from KMS.
+// Newly created key versions are waited for until they are enabled or ctx is done
+func (c *AsymmetricCrypter) FetchPublicKey(ctx context.Context) (string, error) {
+	kms, err := c.service()
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.waitEnabled(ctx, kms); err != nil {
+		return "", err
+	}
+
+	var resp *cloudkms.PublicKey
+	err = c.do(ctx, func() (err error) {
+		resp, err = kms.Projects.Locations.KeyRings.CryptoKeys.CryptoKeyVersions.
+			GetPublicKey(c.KmsKeyVersionName()).Context(ctx).Do()
+		if err != nil {
+			return err
+		}
+		return verifyCRC32C("public key", []byte(resp.Pem), resp.PemCrc32c, c.options.checksumsRequired())
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to fetch public key")
+	}
+
+	if !strings.HasPrefix(resp.Algorithm, "RSA_DECRYPT_OAEP_") || !strings.HasSuffix(resp.Algorithm, "_SHA256") {
+		return "", fmt.Errorf("%s uses %s, only RSA_DECRYPT_OAEP_*_SHA256 keys are supported", c.KmsKeyVersionName(), resp.Algorithm)
+	}
+
+	return resp.Pem, nil
+}
+
+// waitEnabled polls the key version until KMS finished generating it. The public key
+// of a version in PENDING_GENERATION can not be fetched yet
+func (c *AsymmetricCrypter) waitEnabled(ctx context.Context, kms *cloudkms.Service) error {
+	for {
+		var version *cloudkms.CryptoKeyVersion
+		err := c.do(ctx, func() (err error) {
+			version, err = kms.Projects.Locations.KeyRings.CryptoKeys.CryptoKeyVersions.
+				Get(c.KmsKeyVersionName()).Context(ctx).Do()
+			return err
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to get key version")
+		}
+
+		switch version.State {
+		case "ENABLED":
+			return nil
+		case "PENDING_GENERATION":
+			if err := sleep(ctx, keyVersionPollInterval); err != nil {
+				return errors.Wrapf(err, "key version %s is still being generated", c.KmsKeyVersionName())
+			}
+		default:
+			return fmt.Errorf("key version %s is %s, only enabled key versions can be used", c.KmsKeyVersionName(), version.State)
+		}
+	}
+}
+
+func (c *AsymmetricCrypter) publicKey() (*rsa.PublicKey, error) {
+	if c.PublicKey == nil || *c.PublicKey == "" {
+		return nil, fmt.Errorf("no public key is cached for %s", c.KmsKeyVersionName())
+	}
+
+	block, _ := pem.Decode([]byte(*c.PublicKey))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode public key PEM")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse public key")
+	}
+
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is not an RSA key")
+	}
+
+	return rsaKey, nil
+}
+
+// Encrypt encrypts a secret locally with the cached public key.
+// Small values without additional authenticated data are encrypted with RSA-OAEP directly,
+// everything else with a random AES-256-GCM key that is itself encrypted with RSA-OAEP
+func (c *AsymmetricCrypter) Encrypt(ctx context.Context, plainText, aad []byte) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	publicKey, err := c.publicKey()
+	if err != nil {
+		return "", err
+	}
+
+	maxOAEP := publicKey.Size() - 2*sha256.Size - 2
+
+	if len(aad) == 0 && len(plainText) <= maxOAEP {
+		sealed, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, plainText, nil)
+		if err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(append([]byte{asymmetricOAEP}, sealed...)), nil
+	}
+
+	key, err := generateDataKey()
+	if err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, key, nil)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := sealGCMBytes(key, plainText, aad)
+	if err != nil {
+		return "", err
+	}
+
+	output := append([]byte{asymmetricHybrid}, wrappedKey...)
+	output = append(output, sealed...)
+	return base64.StdEncoding.EncodeToString(output), nil
+}
+
+// Decrypt decrypts a secret using KMS AsymmetricDecrypt
+func (c *AsymmetricCrypter) Decrypt(ctx context.Context, cipherText string, aad []byte) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(cipherText)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("cipher text is empty")
+	}
+
+	switch raw[0] {
+	case asymmetricOAEP:
+		return c.asymmetricDecrypt(ctx, raw[1:])
+	case asymmetricHybrid:
+		publicKey, err := c.publicKey()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(raw) < 1+publicKey.Size() {
+			return nil, fmt.Errorf("cipher text is too short")
+		}
+
+		wrappedKey, sealed := raw[1:1+publicKey.Size()], raw[1+publicKey.Size():]
+		key, err := c.asymmetricDecrypt(ctx, wrappedKey)
+		if err != nil {
+			return nil, err
+		}
+
+		return openGCMBytes(key, sealed, aad)
+	default:
+		return nil, fmt.Errorf("unknown asymmetric cipher text format %d", raw[0])
+	}
+}
+
+func (c *AsymmetricCrypter) asymmetricDecrypt(ctx context.Context, cipherText []byte) ([]byte, error) {
+	kms, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	var plainText []byte
+	err = c.do(ctx, func() error {
+		resp, err := kms.Projects.Locations.KeyRings.CryptoKeys.CryptoKeyVersions.
+			AsymmetricDecrypt(c.KmsKeyVersionName(), &cloudkms.AsymmetricDecryptRequest{
+				Ciphertext:       base64.StdEncoding.EncodeToString(cipherText),
+				CiphertextCrc32c: crc32c(cipherText),
+				ForceSendFields:  []string{"CiphertextCrc32c"},
+			}).Context(ctx).Do()
+		if err != nil {
+			return err
+		}
+
+		required := c.options.checksumsRequired()
+		if err := verifyRequestCRC32C("cipher text", resp.VerifiedCiphertextCrc32c, required); err != nil {
+			return err
+		}
+
+		plainText, err = verifyBase64CRC32C("plain text", resp.Plaintext, resp.PlaintextCrc32c, required)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return plainText, nil
+}
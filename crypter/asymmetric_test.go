@@ -0,0 +1,225 @@
+package crypter
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+	cloudkms "google.golang.org/api/cloudkms/v1"
+)
+
+const testKeyVersionName = "projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1"
+
+// kmsEmulator serves the Cloud KMS calls used by AsymmetricCrypter for a single RSA key
+type kmsEmulator struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+}
+
+func newKMSEmulator(t *testing.T) *kmsEmulator {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := &kmsEmulator{key: key}
+	e.Server = httptest.NewServer(http.HandlerFunc(e.serve))
+	return e
+}
+
+func (e *kmsEmulator) serve(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/v1/")
+
+	var resp interface{}
+	switch {
+	case r.Method == "GET" && name == testKeyVersionName:
+		resp = &cloudkms.CryptoKeyVersion{Name: name, State: "ENABLED"}
+	case r.Method == "GET" && name == testKeyVersionName+"/publicKey":
+		der, err := x509.MarshalPKIXPublicKey(&e.key.PublicKey)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+		resp = &cloudkms.PublicKey{
+			Algorithm: "RSA_DECRYPT_OAEP_2048_SHA256",
+			Pem:       pemKey,
+			PemCrc32c: crc32c([]byte(pemKey)),
+		}
+	case r.Method == "POST" && name == testKeyVersionName+":asymmetricDecrypt":
+		var req cloudkms.AsymmetricDecryptRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		cipherText, err := base64.StdEncoding.DecodeString(req.Ciphertext)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		plainText, err := rsa.DecryptOAEP(sha256.New(), nil, e.key, cipherText, nil)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp = &cloudkms.AsymmetricDecryptResponse{
+			Plaintext:                base64.StdEncoding.EncodeToString(plainText),
+			PlaintextCrc32c:          crc32c(plainText),
+			VerifiedCiphertextCrc32c: req.CiphertextCrc32c == crc32c(cipherText),
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// newTestAsymmetricCrypter creates an AsymmetricCrypter talking to e with its public key cached
+func newTestAsymmetricCrypter(t *testing.T, e *kmsEmulator) *AsymmetricCrypter {
+	c := NewAsymmetricCrypter(Config{
+		Project:    stringPtr("p"),
+		Location:   stringPtr("global"),
+		Keyring:    stringPtr("r"),
+		Key:        stringPtr("k"),
+		KeyVersion: stringPtr("1"),
+		PublicKey:  stringPtr(""),
+		Options:    Options{Endpoint: e.URL, NoAuth: true},
+	})
+
+	publicKey, err := c.FetchPublicKey(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	*c.PublicKey = publicKey
+	return c
+}
+
+// asymmetricFormat returns the format marker of an asymmetric cipher text
+func asymmetricFormat(t *testing.T, cipherText string) byte {
+	raw, err := base64.StdEncoding.DecodeString(cipherText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw[0]
+}
+
+func TestAsymmetricRoundTrip(t *testing.T) {
+	e := newKMSEmulator(t)
+	defer e.Close()
+
+	c := newTestAsymmetricCrypter(t, e)
+	ctx := context.Background()
+
+	// 2048 bit RSA-OAEP with SHA-256 encrypts at most 190 bytes directly
+	tests := []struct {
+		name      string
+		plainText []byte
+		aad       []byte
+		format    byte
+	}{
+		{"small", []byte("secret"), nil, asymmetricOAEP},
+		{"OAEP limit", bytes.Repeat([]byte("a"), 190), nil, asymmetricOAEP},
+		{"above OAEP limit", bytes.Repeat([]byte("a"), 191), nil, asymmetricHybrid},
+		{"large", bytes.Repeat([]byte("a"), 64*1024), nil, asymmetricHybrid},
+		{"small with aad", []byte("secret"), []byte("main/API_KEY"), asymmetricHybrid},
+	}
+
+	for _, test := range tests {
+		cipherText, err := c.Encrypt(ctx, test.plainText, test.aad)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if format := asymmetricFormat(t, cipherText); format != test.format {
+			t.Errorf("%s: got format %d, want %d", test.name, format, test.format)
+		}
+
+		plainText, err := c.Decrypt(ctx, cipherText, test.aad)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if !bytes.Equal(plainText, test.plainText) {
+			t.Errorf("%s: decrypted value does not match", test.name)
+		}
+	}
+}
+
+func TestAsymmetricEncryptOnlyNeedsPublicKey(t *testing.T) {
+	e := newKMSEmulator(t)
+	publicKey := *newTestAsymmetricCrypter(t, e).PublicKey
+	e.Close()
+
+	// users without decrypt permissions only have the public key cached in the vault
+	c := NewAsymmetricCrypter(Config{
+		Project:    stringPtr("p"),
+		Location:   stringPtr("global"),
+		Keyring:    stringPtr("r"),
+		Key:        stringPtr("k"),
+		KeyVersion: stringPtr("1"),
+		PublicKey:  &publicKey,
+		Options:    Options{Endpoint: e.URL, NoAuth: true},
+	})
+
+	if _, err := c.Encrypt(context.Background(), bytes.Repeat([]byte("a"), 1024), []byte("main/API_KEY")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAsymmetricSwappedCipherTextFails(t *testing.T) {
+	e := newKMSEmulator(t)
+	defer e.Close()
+
+	c := newTestAsymmetricCrypter(t, e)
+	ctx := context.Background()
+
+	for _, plainText := range [][]byte{[]byte("secret"), bytes.Repeat([]byte("a"), 1024)} {
+		cipherText, err := c.Encrypt(ctx, plainText, []byte("main/API_KEY"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, aad := range [][]byte{[]byte("main/DB_PASSWORD"), []byte("staging/API_KEY"), nil} {
+			if _, err := c.Decrypt(ctx, cipherText, aad); err == nil {
+				t.Errorf("%d byte cipher text bound to main/API_KEY was decrypted with aad %q", len(plainText), aad)
+			}
+		}
+	}
+}
+
+func TestAsymmetricCorruptedCipherTextFails(t *testing.T) {
+	e := newKMSEmulator(t)
+	defer e.Close()
+
+	c := newTestAsymmetricCrypter(t, e)
+	ctx := context.Background()
+
+	cipherText, err := c.Encrypt(ctx, bytes.Repeat([]byte("a"), 1024), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(cipherText)
+
+	tests := map[string][]byte{
+		"empty":          {},
+		"unknown format": append([]byte{9}, raw[1:]...),
+		"truncated":      raw[:100],
+		"flipped bit":    append(append([]byte{}, raw[:len(raw)-1]...), raw[len(raw)-1]^1),
+	}
+
+	for name, corrupted := range tests {
+		if _, err := c.Decrypt(ctx, base64.StdEncoding.EncodeToString(corrupted), nil); err == nil {
+			t.Errorf("%s cipher text was decrypted", name)
+		}
+	}
+}
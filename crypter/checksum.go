@@ -0,0 +1,57 @@
+package crypter
+
+import (
+	"encoding/base64"
+	"hash/crc32"
+
+	"github.com/pkg/errors"
+)
+
+// ErrCorrupted is returned when data sent to or received from KMS does not match its
+// CRC32C checksum. Use errors.Cause to compare wrapped errors
+var ErrCorrupted = errors.New("data was corrupted in transit (CRC32C checksum mismatch)")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// crc32c the CRC32C checksum of data as expected by the KMS API
+func crc32c(data []byte) int64 {
+	return int64(crc32.Checksum(data, castagnoli))
+}
+
+// checksumsRequired reports whether KMS responses must carry checksums. Emulators
+// selected with Endpoint or NoAuth may not support them
+func (o Options) checksumsRequired() bool {
+	return o.Endpoint == "" && !o.NoAuth
+}
+
+// verifyCRC32C returns ErrCorrupted when checksum does not match data. A missing
+// checksum is only accepted when checksums are not required
+func verifyCRC32C(what string, data []byte, checksum int64, required bool) error {
+	// the checksum of empty data is 0 and can not be told apart from a missing one
+	if checksum == 0 && !required {
+		return nil
+	}
+
+	if crc32c(data) != checksum {
+		return errors.Wrap(ErrCorrupted, what)
+	}
+	return nil
+}
+
+// verifyBase64CRC32C decodes a base64 encoded KMS response field and verifies its checksum
+func verifyBase64CRC32C(what, data string, checksum int64, required bool) ([]byte, error) {
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, errors.Wrapf(ErrCorrupted, "%s is not valid base64", what)
+	}
+	return decoded, verifyCRC32C(what, decoded, checksum, required)
+}
+
+// verifyRequestCRC32C returns ErrCorrupted when KMS did not confirm that it verified
+// the checksum of a request field
+func verifyRequestCRC32C(what string, verified, required bool) error {
+	if !verified && required {
+		return errors.Wrapf(ErrCorrupted, "KMS did not verify the %s checksum", what)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package crypter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	cloudkms "google.golang.org/api/cloudkms/v1"
+)
+
+var (
+	generateAccessTokenURL = "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/%s:generateAccessToken"
+	tokenInfoURL           = "https://oauth2.googleapis.com/tokeninfo"
+)
+
+// tokenInfoTimeout how long an identity lookup may take before callers fall back
+const tokenInfoTimeout = 3 * time.Second
+
+// findCredentials loads options.CredentialsFile or the application default credentials
+func findCredentials(ctx context.Context, options Options) (*google.Credentials, error) {
+	if options.CredentialsFile == "" {
+		return google.FindDefaultCredentials(ctx, cloudkms.CloudPlatformScope)
+	}
+
+	data, err := ioutil.ReadFile(options.CredentialsFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read credentials file")
+	}
+
+	credentials, err := google.CredentialsFromJSON(ctx, data, cloudkms.CloudPlatformScope)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load credentials from %s", options.CredentialsFile)
+	}
+	return credentials, nil
+}
+
+// tokenSource returns the token source selected by options. Credentials are read from
+// options.CredentialsFile or the application default credentials and are optionally
+// exchanged for tokens of options.ImpersonateServiceAccount
+func tokenSource(ctx context.Context, options Options) (oauth2.TokenSource, error) {
+	credentials, err := findCredentials(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	source := credentials.TokenSource
+
+	if options.ImpersonateServiceAccount != "" {
+		source = oauth2.ReuseTokenSource(nil, &impersonatedTokenSource{
+			ctx:            ctx,
+			source:         source,
+			serviceAccount: options.ImpersonateServiceAccount,
+		})
+	}
+
+	return source, nil
+}
+
+// Identity returns the email of the Google identity selected by options. Service account
+// credentials carry their email, for user credentials it is looked up with the token
+func Identity(ctx context.Context, options Options) (string, error) {
+	if options.ImpersonateServiceAccount != "" {
+		return options.ImpersonateServiceAccount, nil
+	}
+
+	if options.NoAuth {
+		return "", errors.New("no credentials are used")
+	}
+
+	credentials, err := findCredentials(ctx, options)
+	if err != nil {
+		return "", err
+	}
+
+	var file struct {
+		ClientEmail string `json:"client_email"`
+	}
+	if json.Unmarshal(credentials.JSON, &file) == nil && file.ClientEmail != "" {
+		return file.ClientEmail, nil
+	}
+
+	token, err := credentials.TokenSource.Token()
+	if err != nil {
+		return "", err
+	}
+
+	// the token is sent in the body so it does not end up in proxy or server logs
+	body := url.Values{"access_token": {token.AccessToken}}.Encode()
+	req, err := http.NewRequest("POST", tokenInfoURL, bytes.NewBufferString(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: tokenInfoTimeout}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to look up token info")
+	}
+	defer resp.Body.Close()
+
+	var info struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", errors.Wrap(err, "failed to decode token info")
+	}
+
+	if resp.StatusCode != http.StatusOK || info.Email == "" {
+		return "", fmt.Errorf("token info did not include an email: %s", resp.Status)
+	}
+
+	return info.Email, nil
+}
+
+// newHTTPClient creates an HTTP client authenticated with the credentials selected by options
+func newHTTPClient(ctx context.Context, options Options) (*http.Client, error) {
+	source, err := tokenSource(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return oauth2.NewClient(ctx, source), nil
+}
+
+// impersonatedTokenSource generates short lived access tokens for a service account
+// using the IAM credentials API. The caller needs roles/iam.serviceAccountTokenCreator
+type impersonatedTokenSource struct {
+	ctx            context.Context
+	source         oauth2.TokenSource
+	serviceAccount string
+}
+
+type generateAccessTokenRequest struct {
+	Scope    []string `json:"scope"`
+	Lifetime string   `json:"lifetime"`
+}
+
+type generateAccessTokenResponse struct {
+	AccessToken string `json:"accessToken"`
+	ExpireTime  string `json:"expireTime"`
+}
+
+// Token generates a new access token for the impersonated service account
+func (s *impersonatedTokenSource) Token() (*oauth2.Token, error) {
+	body, err := json.Marshal(generateAccessTokenRequest{
+		Scope:    []string{cloudkms.CloudPlatformScope},
+		Lifetime: "3600s",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf(generateAccessTokenURL, s.serviceAccount), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := oauth2.NewClient(s.ctx, s.source).Do(req.WithContext(s.ctx))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to impersonate %s", s.serviceAccount)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to impersonate %s: %s %s", s.serviceAccount, resp.Status, respBody)
+	}
+
+	var token generateAccessTokenResponse
+	if err := json.Unmarshal(respBody, &token); err != nil {
+		return nil, errors.Wrap(err, "failed to decode impersonated access token")
+	}
+
+	expiry, err := time.Parse(time.RFC3339, token.ExpireTime)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse impersonated access token expiry")
+	}
+
+	return &oauth2.Token{
+		AccessToken: token.AccessToken,
+		TokenType:   "Bearer",
+		Expiry:      expiry,
+	}, nil
+}
@@ -1,75 +1,110 @@
 package crypter
 
 import (
-	"encoding/base64"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"golang.org/x/net/context"
-	"golang.org/x/oauth2/google"
-	cloudkms "google.golang.org/api/cloudkms/v1"
 )
 
-// Crypter encrypt and descrypt secrets using KMS
-type Crypter struct {
+// BackendKMS is the name of the Google Cloud KMS backend
+const BackendKMS = "kms"
+
+// Encrypter encrypts plain text into a cipher text suitable for storing in a vault
+// the additional authenticated data (aad) is not stored but must be supplied again to decrypt
+type Encrypter interface {
+	Encrypt(ctx context.Context, plainText, aad []byte) (string, error)
+}
+
+// Decrypter decrypts cipher text produced by the matching Encrypter
+type Decrypter interface {
+	Decrypt(ctx context.Context, cipherText string, aad []byte) ([]byte, error)
+}
+
+// Crypter encrypts and decrypts secrets using one of the registered backends
+type Crypter interface {
+	Encrypter
+	Decrypter
+}
+
+// Config settings used to construct a Crypter
+// the pointers allow a crypter to observe a vault being loaded after it was created
+type Config struct {
+	Backend  string
 	Project  *string
 	Location *string
 	Keyring  *string
 	Key      *string
-	kms      *cloudkms.Service
+	// KeyVersion and PublicKey are only used by asymmetric backends
+	KeyVersion *string
+	PublicKey  *string
+	Options    Options
 }
 
-// NewCrypter creates a new Crypter instance
-func NewCrypter(project, location, keyring, key *string) (*Crypter, error) {
-	crypter := &Crypter{
-		Project:  project,
-		Location: location,
-		Keyring:  keyring,
-		Key:      key,
-	}
+// Options tune how backends talk to remote services
+type Options struct {
+	// MaxRetries number of times a transient error is retried, zero disables retries
+	MaxRetries int
+	// RateLimit maximum requests per second, zero means unlimited
+	RateLimit float64
+	// OnRetry is called before a failed request is retried
+	OnRetry func(attempt int, delay time.Duration, err error)
+	// CredentialsFile path to a service account or user credentials JSON file
+	// the application default credentials are used when empty
+	CredentialsFile string
+	// ImpersonateServiceAccount email of a service account to act as
+	ImpersonateServiceAccount string
+	// Endpoint base URL of an alternative Cloud KMS API, e.g. an emulator
+	Endpoint string
+	// NoAuth sends unauthenticated requests, only useful together with Endpoint
+	NoAuth bool
+}
 
-	ctx := context.Background()
-	client, err := google.DefaultClient(ctx, cloudkms.CloudPlatformScope)
-	if err != nil {
-		return crypter, err
-	}
+// Factory constructs a Crypter for a backend
+type Factory func(config Config) (Crypter, error)
 
-	cloudkmsService, err := cloudkms.New(client)
-	if err != nil {
-		return crypter, err
-	}
+var backends = map[string]Factory{}
 
-	crypter.kms = cloudkmsService
-	return crypter, nil
+// Register makes a backend available under the supplied name
+func Register(name string, factory Factory) {
+	backends[name] = factory
 }
 
-// KmsKeyName name of the kms key
-func (c *Crypter) KmsKeyName() string {
-	return fmt.Sprintf("projects/%s/locations/%s/keyRings/%s/cryptoKeys/%s",
-		*c.Project, *c.Location, *c.Keyring, *c.Key)
+// Backends returns the names of all registered backends
+func Backends() []string {
+	names := []string{}
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
-// Encrypt encrypts a secret using Google KMS
-func (c *Crypter) Encrypt(plainText []byte) (string, error) {
-	resp, err := c.kms.Projects.Locations.KeyRings.CryptoKeys.
-		Encrypt(c.KmsKeyName(), &cloudkms.EncryptRequest{
-			Plaintext: base64.StdEncoding.EncodeToString(plainText),
-		}).Do()
+// New creates a Crypter using the backend named in the config
+// an empty backend defaults to Google Cloud KMS
+func New(config Config) (Crypter, error) {
+	if config.Backend == "" {
+		config.Backend = BackendKMS
+	}
 
-	if err != nil {
-		return "", err
+	factory, ok := backends[config.Backend]
+	if !ok {
+		return nil, fmt.Errorf("%s is not a supported crypter backend (%s)",
+			config.Backend, strings.Join(Backends(), ", "))
 	}
 
-	return resp.Ciphertext, nil
+	return factory(config)
 }
 
-// Decrypt decrypts a secret using Google KMS
-func (c *Crypter) Decrypt(cipherText string) ([]byte, error) {
-	resp, err := c.kms.Projects.Locations.KeyRings.CryptoKeys.
-		Decrypt(c.KmsKeyName(), &cloudkms.DecryptRequest{
-			Ciphertext: cipherText,
-		}).Do()
-	if err != nil {
-		return nil, err
-	}
-	return base64.StdEncoding.DecodeString(resp.Plaintext)
+// KmsKeyName name of the kms key
+func KmsKeyName(project, location, keyring, key string) string {
+	return fmt.Sprintf("projects/%s/locations/%s/keyRings/%s/cryptoKeys/%s",
+		project, location, keyring, key)
+}
+
+// KmsKeyVersionName name of a specific version of a kms key
+func KmsKeyVersionName(project, location, keyring, key, version string) string {
+	return fmt.Sprintf("%s/cryptoKeyVersions/%s", KmsKeyName(project, location, keyring, key), version)
 }
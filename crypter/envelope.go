@@ -0,0 +1,117 @@
+package crypter
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// WrappedDataKey a copy of a data key encrypted by one of its recipients
+type WrappedDataKey struct {
+	Decrypter  Decrypter
+	CipherText string
+}
+
+// Envelope encrypts secrets locally with AES-256-GCM using a data key
+// the data key is stored wrapped (encrypted) by one or more Crypters such as KMS
+// and is only unwrapped once no matter how many secrets are processed
+type Envelope struct {
+	wrappedKeys []WrappedDataKey
+	mu          sync.Mutex
+	key         []byte
+}
+
+// NewEnvelope creates an Envelope from a data key wrapped by kek
+func NewEnvelope(kek Decrypter, wrappedKey string) *Envelope {
+	return NewMultiEnvelope(WrappedDataKey{Decrypter: kek, CipherText: wrappedKey})
+}
+
+// NewMultiEnvelope creates an Envelope from several wrapped copies of the same data key
+// the copies are tried in order until one of them can be unwrapped
+func NewMultiEnvelope(wrappedKeys ...WrappedDataKey) *Envelope {
+	return &Envelope{wrappedKeys: wrappedKeys}
+}
+
+// NewDataKey generates a new random data key and wraps it using kek
+func NewDataKey(ctx context.Context, kek Encrypter) (*Envelope, error) {
+	key, err := generateDataKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate data key")
+	}
+
+	wrappedKey, err := kek.Encrypt(ctx, key, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to wrap data key")
+	}
+
+	envelope := &Envelope{
+		wrappedKeys: []WrappedDataKey{{CipherText: wrappedKey}},
+		key:         key,
+	}
+	return envelope, nil
+}
+
+// WrappedKey the data key encrypted by the primary key encryption key
+func (e *Envelope) WrappedKey() string {
+	if len(e.wrappedKeys) == 0 {
+		return ""
+	}
+	return e.wrappedKeys[0].CipherText
+}
+
+// Wrap encrypts the data key for an additional recipient
+func (e *Envelope) Wrap(ctx context.Context, kek Encrypter) (string, error) {
+	key, err := e.dataKey(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := kek.Encrypt(ctx, key, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to wrap data key")
+	}
+
+	return wrappedKey, nil
+}
+
+func (e *Envelope) dataKey(ctx context.Context) ([]byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// only a successfully unwrapped key is cached, failures such as a cancelled
+	// context are retried by the next caller
+	if e.key != nil {
+		return e.key, nil
+	}
+
+	failures := []string{}
+	for _, wrappedKey := range e.wrappedKeys {
+		key, err := wrappedKey.Decrypter.Decrypt(ctx, wrappedKey.CipherText, nil)
+		if err == nil {
+			e.key = key
+			return e.key, nil
+		}
+		failures = append(failures, err.Error())
+	}
+	return nil, errors.Errorf("failed to unwrap data key with any recipient: %s", strings.Join(failures, "; "))
+}
+
+// Encrypt encrypts a secret using the data key
+func (e *Envelope) Encrypt(ctx context.Context, plainText, aad []byte) (string, error) {
+	key, err := e.dataKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	return sealGCM(key, plainText, aad)
+}
+
+// Decrypt decrypts a secret using the data key
+func (e *Envelope) Decrypt(ctx context.Context, cipherText string, aad []byte) ([]byte, error) {
+	key, err := e.dataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return openGCM(key, cipherText, aad)
+}
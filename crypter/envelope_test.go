@@ -0,0 +1,209 @@
+package crypter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+// fakeDecrypter records its calls and fails until it is told to succeed
+type fakeDecrypter struct {
+	name  string
+	calls *[]string
+	key   []byte
+	fail  bool
+}
+
+func (d *fakeDecrypter) Decrypt(ctx context.Context, cipherText string, aad []byte) ([]byte, error) {
+	*d.calls = append(*d.calls, d.name)
+	if d.fail {
+		return nil, fmt.Errorf("%s can not unwrap", d.name)
+	}
+	return d.key, nil
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	defer useTempKeyDir(t)()
+
+	kek := newTestLocalCrypter(t, "main")
+	ctx := context.Background()
+
+	envelope, err := NewDataKey(ctx, kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aad := []byte("main/API_KEY")
+	cipherText, err := envelope.Encrypt(ctx, []byte("secret"), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a vault loaded later only has the wrapped data key
+	reopened := NewEnvelope(kek, envelope.WrappedKey())
+	plainText, err := reopened.Decrypt(ctx, cipherText, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plainText, []byte("secret")) {
+		t.Errorf("got %q, want secret", plainText)
+	}
+}
+
+func TestEnvelopeSwappedCipherTextFails(t *testing.T) {
+	defer useTempKeyDir(t)()
+
+	ctx := context.Background()
+	envelope, err := NewDataKey(ctx, newTestLocalCrypter(t, "main"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cipherText, err := envelope.Encrypt(ctx, []byte("secret"), []byte("main/API_KEY"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, aad := range [][]byte{[]byte("main/DB_PASSWORD"), []byte("staging/API_KEY"), nil} {
+		if _, err := envelope.Decrypt(ctx, cipherText, aad); err == nil {
+			t.Errorf("cipher text bound to main/API_KEY was decrypted with aad %q", aad)
+		}
+	}
+}
+
+func TestEnvelopeWrongKeyEncryptionKeyFails(t *testing.T) {
+	defer useTempKeyDir(t)()
+
+	ctx := context.Background()
+	envelope, err := NewDataKey(ctx, newTestLocalCrypter(t, "main"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := NewEnvelope(newTestLocalCrypter(t, "staging"), envelope.WrappedKey())
+	if _, err := reopened.Decrypt(ctx, "", nil); err == nil {
+		t.Error("data key was unwrapped with another key")
+	}
+}
+
+func TestEnvelopeRetriesFailedUnwrap(t *testing.T) {
+	key, err := generateDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := []string{}
+	kek := &fakeDecrypter{name: "kms", calls: &calls, key: key, fail: true}
+	envelope := NewEnvelope(kek, "wrapped")
+	ctx := context.Background()
+
+	if _, err := envelope.Encrypt(ctx, []byte("secret"), nil); err == nil {
+		t.Fatal("expected encrypting to fail while the data key can not be unwrapped")
+	}
+
+	// a failed unwrap, e.g. a cancelled context, must not be cached
+	kek.fail = false
+	cipherText, err := envelope.Encrypt(ctx, []byte("secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := envelope.Decrypt(ctx, cipherText, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(calls) != 2 {
+		t.Errorf("data key was unwrapped %d times, want once per failure and once on success", len(calls))
+	}
+}
+
+func TestMultiEnvelopeTriesRecipientsInOrder(t *testing.T) {
+	key, err := generateDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := []string{}
+	envelope := NewMultiEnvelope(
+		WrappedDataKey{Decrypter: &fakeDecrypter{name: "first", calls: &calls, fail: true}, CipherText: "1"},
+		WrappedDataKey{Decrypter: &fakeDecrypter{name: "second", calls: &calls, key: key}, CipherText: "2"},
+		WrappedDataKey{Decrypter: &fakeDecrypter{name: "third", calls: &calls, key: key}, CipherText: "3"},
+	)
+	ctx := context.Background()
+
+	cipherText, err := envelope.Encrypt(ctx, []byte("secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := envelope.Decrypt(ctx, cipherText, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the third recipient is never asked and the unwrapped key is reused
+	if got := fmt.Sprint(calls); got != "[first second]" {
+		t.Errorf("recipients were tried in order %s, want [first second]", got)
+	}
+	if envelope.WrappedKey() != "1" {
+		t.Errorf("got primary wrapped key %q, want the first recipient's", envelope.WrappedKey())
+	}
+}
+
+func TestMultiEnvelopeEveryRecipientFails(t *testing.T) {
+	calls := []string{}
+	envelope := NewMultiEnvelope(
+		WrappedDataKey{Decrypter: &fakeDecrypter{name: "first", calls: &calls, fail: true}, CipherText: "1"},
+		WrappedDataKey{Decrypter: &fakeDecrypter{name: "second", calls: &calls, fail: true}, CipherText: "2"},
+	)
+
+	_, err := envelope.Encrypt(context.Background(), []byte("secret"), nil)
+	if err == nil {
+		t.Fatal("expected an error when no recipient can unwrap the data key")
+	}
+	for _, name := range []string{"first", "second"} {
+		if !strings.Contains(err.Error(), name+" can not unwrap") {
+			t.Errorf("error %q does not mention the %s recipient", err, name)
+		}
+	}
+}
+
+func TestMultiEnvelopeWrapForAnotherRecipient(t *testing.T) {
+	defer useTempKeyDir(t)()
+
+	main := newTestLocalCrypter(t, "main")
+	staging := newTestLocalCrypter(t, "staging")
+	ctx := context.Background()
+
+	envelope, err := NewDataKey(ctx, main)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cipherText, err := envelope.Encrypt(ctx, []byte("secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrappedKey, err := envelope.Wrap(ctx, staging)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a recipient without the main key unwraps its own copy
+	reopened := NewMultiEnvelope(
+		WrappedDataKey{Decrypter: NewLocalCrypter(stringPtr("missing")), CipherText: envelope.WrappedKey()},
+		WrappedDataKey{Decrypter: staging, CipherText: wrappedKey},
+	)
+	plainText, err := reopened.Decrypt(ctx, cipherText, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plainText, []byte("secret")) {
+		t.Errorf("got %q, want secret", plainText)
+	}
+}
+
+// stringPtr returns a pointer to s for Config style fields
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,78 @@
+package crypter
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// dataKeySize size in bytes of the AES-256 keys used for local encryption
+const dataKeySize = 32
+
+// generateDataKey creates a random AES-256 key
+func generateDataKey() ([]byte, error) {
+	key := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// sealGCM encrypts plainText with AES-GCM and returns base64(nonce || cipherText)
+func sealGCM(key, plainText, aad []byte) (string, error) {
+	sealed, err := sealGCMBytes(key, plainText, aad)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openGCM decrypts a cipher text produced by sealGCM
+func openGCM(key []byte, cipherText string, aad []byte) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(cipherText)
+	if err != nil {
+		return nil, err
+	}
+	return openGCMBytes(key, sealed, aad)
+}
+
+// sealGCMBytes encrypts plainText with AES-GCM and returns nonce || cipherText
+func sealGCMBytes(key, plainText, aad []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plainText, aad), nil
+}
+
+// openGCMBytes decrypts a cipher text produced by sealGCMBytes
+func openGCMBytes(key, sealed, aad []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("cipher text is too short")
+	}
+
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
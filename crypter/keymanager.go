@@ -0,0 +1,116 @@
+package crypter
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	cloudkms "google.golang.org/api/cloudkms/v1"
+)
+
+// KeySpec settings used when creating a new Cloud KMS crypto key
+type KeySpec struct {
+	// Backend the gvault backend the key is created for (kms or kms-asymmetric)
+	Backend string
+	// RotationPeriod how often a new primary version is created, zero disables rotation
+	RotationPeriod time.Duration
+	// ProtectionLevel SOFTWARE or HSM
+	ProtectionLevel string
+}
+
+// KeyManager lists and creates Cloud KMS keyrings and crypto keys
+type KeyManager struct {
+	kms *cloudkms.Service
+	*retrier
+}
+
+// NewKeyManager creates a new KeyManager instance
+func NewKeyManager(ctx context.Context, options Options) (*KeyManager, error) {
+	kms, err := newKMSService(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyManager{kms: kms, retrier: newRetrier(options)}, nil
+}
+
+// KeyRings lists the names of the keyrings in a project location
+func (m *KeyManager) KeyRings(ctx context.Context, project, location string) ([]string, error) {
+	parent := fmt.Sprintf("projects/%s/locations/%s", project, location)
+	names := []string{}
+
+	err := m.kms.Projects.Locations.KeyRings.List(parent).Pages(ctx, func(resp *cloudkms.ListKeyRingsResponse) error {
+		for _, keyring := range resp.KeyRings {
+			names = append(names, path.Base(keyring.Name))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list keyrings")
+	}
+
+	return names, nil
+}
+
+// CryptoKeys lists the names of the crypto keys in a keyring
+func (m *KeyManager) CryptoKeys(ctx context.Context, project, location, keyring string) ([]string, error) {
+	parent := fmt.Sprintf("projects/%s/locations/%s/keyRings/%s", project, location, keyring)
+	names := []string{}
+
+	err := m.kms.Projects.Locations.KeyRings.CryptoKeys.List(parent).Pages(ctx, func(resp *cloudkms.ListCryptoKeysResponse) error {
+		for _, key := range resp.CryptoKeys {
+			names = append(names, path.Base(key.Name))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list crypto keys")
+	}
+
+	return names, nil
+}
+
+// CreateKeyRing creates a new keyring
+func (m *KeyManager) CreateKeyRing(ctx context.Context, project, location, keyring string) error {
+	parent := fmt.Sprintf("projects/%s/locations/%s", project, location)
+
+	err := m.do(ctx, func() error {
+		_, err := m.kms.Projects.Locations.KeyRings.
+			Create(parent, &cloudkms.KeyRing{}).KeyRingId(keyring).Context(ctx).Do()
+		return err
+	})
+
+	return errors.Wrapf(err, "failed to create keyring %s", keyring)
+}
+
+// CreateCryptoKey creates a new crypto key suitable for the backend in spec
+func (m *KeyManager) CreateCryptoKey(ctx context.Context, project, location, keyring, key string, spec KeySpec) error {
+	parent := fmt.Sprintf("projects/%s/locations/%s/keyRings/%s", project, location, keyring)
+
+	cryptoKey := &cloudkms.CryptoKey{
+		Purpose: "ENCRYPT_DECRYPT",
+		VersionTemplate: &cloudkms.CryptoKeyVersionTemplate{
+			Algorithm:       "GOOGLE_SYMMETRIC_ENCRYPTION",
+			ProtectionLevel: spec.ProtectionLevel,
+		},
+	}
+
+	if spec.Backend == BackendKMSAsymmetric {
+		// asymmetric keys do not support automatic rotation
+		cryptoKey.Purpose = "ASYMMETRIC_DECRYPT"
+		cryptoKey.VersionTemplate.Algorithm = "RSA_DECRYPT_OAEP_3072_SHA256"
+	} else if spec.RotationPeriod > 0 {
+		cryptoKey.RotationPeriod = fmt.Sprintf("%ds", int64(spec.RotationPeriod/time.Second))
+		cryptoKey.NextRotationTime = time.Now().Add(spec.RotationPeriod).UTC().Format(time.RFC3339)
+	}
+
+	err := m.do(ctx, func() error {
+		_, err := m.kms.Projects.Locations.KeyRings.CryptoKeys.
+			Create(parent, cryptoKey).CryptoKeyId(key).Context(ctx).Do()
+		return err
+	})
+
+	return errors.Wrapf(err, "failed to create crypto key %s", key)
+}
@@ -0,0 +1,144 @@
+package crypter
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"golang.org/x/net/context"
+	cloudkms "google.golang.org/api/cloudkms/v1"
+)
+
+func init() {
+	Register(BackendKMS, func(config Config) (Crypter, error) {
+		return NewKMSCrypter(config.Project, config.Location, config.Keyring, config.Key, config.Options)
+	})
+}
+
+// KMSCrypter encrypt and descrypt secrets using KMS
+type KMSCrypter struct {
+	Project  *string
+	Location *string
+	Keyring  *string
+	Key      *string
+	kms      *cloudkms.Service
+	*retrier
+}
+
+// NewKMSCrypter creates a new KMSCrypter instance
+func NewKMSCrypter(project, location, keyring, key *string, options Options) (*KMSCrypter, error) {
+	crypter := &KMSCrypter{
+		Project:  project,
+		Location: location,
+		Keyring:  keyring,
+		Key:      key,
+		retrier:  newRetrier(options),
+	}
+
+	cloudkmsService, err := newKMSService(context.Background(), options)
+	if err != nil {
+		return crypter, err
+	}
+
+	crypter.kms = cloudkmsService
+	return crypter, nil
+}
+
+// newKMSService creates a Cloud KMS client authenticated as selected by options
+func newKMSService(ctx context.Context, options Options) (*cloudkms.Service, error) {
+	client := http.DefaultClient
+
+	if !options.NoAuth {
+		var err error
+		if client, err = newHTTPClient(ctx, options); err != nil {
+			return nil, err
+		}
+	}
+
+	service, err := cloudkms.New(client)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Endpoint != "" {
+		// the generated client appends request paths to the base path
+		service.BasePath = strings.TrimSuffix(options.Endpoint, "/") + "/"
+	}
+
+	return service, nil
+}
+
+// KmsKeyName name of the kms key
+func (c *KMSCrypter) KmsKeyName() string {
+	return KmsKeyName(*c.Project, *c.Location, *c.Keyring, *c.Key)
+}
+
+// Encrypt encrypts a secret using Google KMS. Checksums of the request and
+// response are verified and mismatches are reported as ErrCorrupted
+func (c *KMSCrypter) Encrypt(ctx context.Context, plainText, aad []byte) (string, error) {
+	var resp *cloudkms.EncryptResponse
+	err := c.do(ctx, func() (err error) {
+		resp, err = c.kms.Projects.Locations.KeyRings.CryptoKeys.
+			Encrypt(c.KmsKeyName(), &cloudkms.EncryptRequest{
+				Plaintext:                         base64.StdEncoding.EncodeToString(plainText),
+				PlaintextCrc32c:                   crc32c(plainText),
+				AdditionalAuthenticatedData:       base64.StdEncoding.EncodeToString(aad),
+				AdditionalAuthenticatedDataCrc32c: crc32c(aad),
+				ForceSendFields:                   []string{"PlaintextCrc32c", "AdditionalAuthenticatedDataCrc32c"},
+			}).Context(ctx).Do()
+		if err != nil {
+			return err
+		}
+
+		required := c.options.checksumsRequired()
+		if err := verifyRequestCRC32C("plain text", resp.VerifiedPlaintextCrc32c, required); err != nil {
+			return err
+		}
+		if err := verifyRequestCRC32C("additional authenticated data", resp.VerifiedAdditionalAuthenticatedDataCrc32c, required); err != nil {
+			return err
+		}
+
+		_, err = verifyBase64CRC32C("cipher text", resp.Ciphertext, resp.CiphertextCrc32c, required)
+		return err
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Ciphertext, nil
+}
+
+// Decrypt decrypts a secret using Google KMS. Checksums of the request and
+// response are verified and mismatches are reported as ErrCorrupted
+func (c *KMSCrypter) Decrypt(ctx context.Context, cipherText string, aad []byte) ([]byte, error) {
+	rawCipherText, err := base64.StdEncoding.DecodeString(cipherText)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid cipher text")
+	}
+
+	var plainText []byte
+	err = c.do(ctx, func() error {
+		resp, err := c.kms.Projects.Locations.KeyRings.CryptoKeys.
+			Decrypt(c.KmsKeyName(), &cloudkms.DecryptRequest{
+				Ciphertext:                        cipherText,
+				CiphertextCrc32c:                  crc32c(rawCipherText),
+				AdditionalAuthenticatedData:       base64.StdEncoding.EncodeToString(aad),
+				AdditionalAuthenticatedDataCrc32c: crc32c(aad),
+				ForceSendFields:                   []string{"CiphertextCrc32c", "AdditionalAuthenticatedDataCrc32c"},
+			}).Context(ctx).Do()
+		if err != nil {
+			return err
+		}
+
+		// KMS rejects decrypt requests with mismatching checksums itself
+		plainText, err = verifyBase64CRC32C("plain text", resp.Plaintext, resp.PlaintextCrc32c, c.options.checksumsRequired())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plainText, nil
+}
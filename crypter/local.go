@@ -0,0 +1,112 @@
+package crypter
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// BackendLocal is the name of the local key file backend
+const BackendLocal = "local"
+
+// LocalKeyDir the directory local backend keys are stored in
+// keys are intentionally kept outside of the project so they are never committed
+var LocalKeyDir = filepath.Join(os.Getenv("HOME"), ".config", "gvault", "keys")
+
+func init() {
+	Register(BackendLocal, func(config Config) (Crypter, error) {
+		return NewLocalCrypter(config.Key), nil
+	})
+}
+
+// LocalCrypter encrypts and decrypts secrets with AES-GCM using a key file on disk
+// it is intended for offline development and tests and does not require GCP credentials
+type LocalCrypter struct {
+	Key *string
+}
+
+// NewLocalCrypter creates a new LocalCrypter instance
+func NewLocalCrypter(key *string) *LocalCrypter {
+	return &LocalCrypter{Key: key}
+}
+
+// KeyPath path of the key file used by this crypter
+func (c *LocalCrypter) KeyPath() string {
+	return filepath.Join(LocalKeyDir, *c.Key)
+}
+
+// CreateKeyIfNotExists generates a new random key file if one does not already exist
+func (c *LocalCrypter) CreateKeyIfNotExists() error {
+	if _, err := os.Stat(c.KeyPath()); err == nil || !os.IsNotExist(err) {
+		return err
+	}
+
+	key, err := generateDataKey()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(LocalKeyDir, 0700); err != nil {
+		return errors.Wrap(err, "failed to create local key directory")
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(key) + "\n"
+	if err := ioutil.WriteFile(c.KeyPath(), []byte(encoded), 0600); err != nil {
+		return errors.Wrap(err, "failed to write local key")
+	}
+
+	return nil
+}
+
+func (c *LocalCrypter) readKey() ([]byte, error) {
+	encoded, err := ioutil.ReadFile(c.KeyPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no local key found at %s", c.KeyPath())
+		}
+		return nil, errors.Wrap(err, "failed to read local key")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode local key")
+	}
+
+	if len(key) != dataKeySize {
+		return nil, fmt.Errorf("local key %s must be %d bytes", c.KeyPath(), dataKeySize)
+	}
+
+	return key, nil
+}
+
+// Encrypt encrypts a secret using the local key
+func (c *LocalCrypter) Encrypt(ctx context.Context, plainText, aad []byte) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	key, err := c.readKey()
+	if err != nil {
+		return "", err
+	}
+	return sealGCM(key, plainText, aad)
+}
+
+// Decrypt decrypts a secret using the local key
+func (c *LocalCrypter) Decrypt(ctx context.Context, cipherText string, aad []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	key, err := c.readKey()
+	if err != nil {
+		return nil, err
+	}
+	return openGCM(key, cipherText, aad)
+}
@@ -0,0 +1,91 @@
+package crypter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+// useTempKeyDir stores local backend keys in a new temporary directory
+// and returns a function restoring the previous key directory
+func useTempKeyDir(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "gvault-keys-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDir := LocalKeyDir
+	LocalKeyDir = dir
+
+	return func() {
+		LocalKeyDir = keyDir
+		os.RemoveAll(dir)
+	}
+}
+
+// newTestLocalCrypter creates a LocalCrypter with a new random key
+func newTestLocalCrypter(t *testing.T, name string) *LocalCrypter {
+	c := NewLocalCrypter(&name)
+	if err := c.CreateKeyIfNotExists(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestLocalRoundTrip(t *testing.T) {
+	defer useTempKeyDir(t)()
+
+	c := newTestLocalCrypter(t, "main")
+	ctx := context.Background()
+
+	for _, aad := range [][]byte{nil, []byte("main/API_KEY")} {
+		cipherText, err := c.Encrypt(ctx, []byte("secret"), aad)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		plainText, err := c.Decrypt(ctx, cipherText, aad)
+		if err != nil {
+			t.Fatalf("decrypt with aad %q: %s", aad, err)
+		}
+		if !bytes.Equal(plainText, []byte("secret")) {
+			t.Errorf("got %q, want secret", plainText)
+		}
+	}
+}
+
+func TestLocalSwappedCipherTextFails(t *testing.T) {
+	defer useTempKeyDir(t)()
+
+	c := newTestLocalCrypter(t, "main")
+	ctx := context.Background()
+
+	cipherText, err := c.Encrypt(ctx, []byte("secret"), []byte("main/API_KEY"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a cipher text copied to another secret or vault is decrypted with different aad
+	for _, aad := range [][]byte{[]byte("main/DB_PASSWORD"), []byte("staging/API_KEY"), nil} {
+		if _, err := c.Decrypt(ctx, cipherText, aad); err == nil {
+			t.Errorf("cipher text bound to main/API_KEY was decrypted with aad %q", aad)
+		}
+	}
+}
+
+func TestLocalWrongKeyFails(t *testing.T) {
+	defer useTempKeyDir(t)()
+
+	ctx := context.Background()
+	cipherText, err := newTestLocalCrypter(t, "main").Encrypt(ctx, []byte("secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newTestLocalCrypter(t, "staging").Decrypt(ctx, cipherText, nil); err == nil {
+		t.Error("cipher text was decrypted with another key")
+	}
+}
@@ -0,0 +1,129 @@
+package crypter
+
+import (
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"google.golang.org/api/googleapi"
+)
+
+// DefaultMaxRetries the number of times a transient KMS error is retried by default
+const DefaultMaxRetries = 5
+
+const (
+	initialBackoff = 200 * time.Millisecond
+	maxBackoff     = 10 * time.Second
+)
+
+// Stats is implemented by crypters that report how their requests were handled
+type Stats interface {
+	Retries() uint64
+}
+
+// retrier retries transient errors with jittered exponential backoff
+// and spaces out requests to respect a client side rate limit
+type retrier struct {
+	options Options
+	retries uint64
+	mu      sync.Mutex
+	next    time.Time
+}
+
+func newRetrier(options Options) *retrier {
+	return &retrier{options: options}
+}
+
+// Retries the total number of retried requests
+func (r *retrier) Retries() uint64 {
+	return atomic.LoadUint64(&r.retries)
+}
+
+// do calls fn until it succeeds, fails with a permanent error or runs out of retries
+func (r *retrier) do(ctx context.Context, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		if err := r.wait(ctx); err != nil {
+			return err
+		}
+
+		err := fn()
+		if err == nil || attempt >= r.options.MaxRetries || !isTransient(err) {
+			return err
+		}
+
+		delay := backoff(attempt)
+		atomic.AddUint64(&r.retries, 1)
+		if r.options.OnRetry != nil {
+			r.options.OnRetry(attempt+1, delay, err)
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// wait blocks until the rate limit allows another request
+func (r *retrier) wait(ctx context.Context) error {
+	if r.options.RateLimit <= 0 {
+		return ctx.Err()
+	}
+
+	interval := time.Duration(float64(time.Second) / r.options.RateLimit)
+
+	r.mu.Lock()
+	now := time.Now()
+	if r.next.Before(now) {
+		r.next = now
+	}
+	delay := r.next.Sub(now)
+	r.next = r.next.Add(interval)
+	r.mu.Unlock()
+
+	return sleep(ctx, delay)
+}
+
+// backoff returns a random delay between zero and an exponentially growing cap
+func backoff(attempt int) time.Duration {
+	ceiling := maxBackoff
+	if attempt < 16 {
+		if exp := initialBackoff << uint(attempt); exp < maxBackoff {
+			ceiling = exp
+		}
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isTransient reports whether a request that failed with err is worth retrying
+func isTransient(err error) bool {
+	switch e := errors.Cause(err).(type) {
+	case *googleapi.Error:
+		switch e.Code {
+		case 429, 500, 502, 503, 504:
+			return true
+		}
+	case net.Error:
+		return e.Timeout()
+	}
+	return false
+}
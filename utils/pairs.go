@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+var envNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Pair a KEY=VALUE argument
+type Pair struct {
+	Key   string
+	Value string
+	// Quoted values are used literally and never read from a file or stdin
+	Quoted bool
+}
+
+// ValidateEnvName checks that name can be used as an environment variable
+func ValidateEnvName(name string) error {
+	if !envNameRegexp.MatchString(name) {
+		return fmt.Errorf("%s is not a valid name, names must match %s", name, envNameRegexp)
+	}
+	return nil
+}
+
+// ParsePair parses a dotenv style KEY=VALUE argument. It is split on the first = and
+// the value may be quoted. Double quoted values support the escapes \n \r \t \\ \" and \'
+func ParsePair(arg string) (Pair, error) {
+	parts := strings.SplitN(arg, "=", 2)
+	if len(parts) != 2 {
+		return Pair{}, fmt.Errorf("%s is not a valid KEY=VALUE pair", arg)
+	}
+
+	pair := Pair{Key: strings.TrimSpace(parts[0]), Value: parts[1]}
+	if err := ValidateEnvName(pair.Key); err != nil {
+		return Pair{}, err
+	}
+
+	value := pair.Value
+	if len(value) < 2 {
+		return pair, nil
+	}
+
+	switch quote := value[0]; {
+	case quote == '\'' && value[len(value)-1] == '\'':
+		pair.Value = value[1 : len(value)-1]
+		pair.Quoted = true
+	case quote == '"' && value[len(value)-1] == '"':
+		unescaped, err := unescape(value[1 : len(value)-1])
+		if err != nil {
+			return Pair{}, fmt.Errorf("invalid value for %s: %s", pair.Key, err)
+		}
+		pair.Value = unescaped
+		pair.Quoted = true
+	}
+
+	return pair, nil
+}
+
+// Resolve returns the value of the pair. Unquoted values of - are read from stdin
+// and values starting with @ are read from the file that follows
+func (p Pair) Resolve() (string, error) {
+	if p.Quoted {
+		return p.Value, nil
+	}
+
+	if p.ReadsStdin() {
+		return ReadStdinValue(), nil
+	}
+
+	if strings.HasPrefix(p.Value, "@") {
+		bytes, err := ioutil.ReadFile(p.Value[1:])
+		if err != nil {
+			return "", err
+		}
+		return string(bytes), nil
+	}
+
+	return p.Value, nil
+}
+
+// ReadsStdin reports whether the value of the pair is read from stdin
+func (p Pair) ReadsStdin() bool {
+	return !p.Quoted && p.Value == "-"
+}
+
+// ReadStdinValue reads a single value from stdin without the trailing
+// newline added by echo or a here string
+func ReadStdinValue() string {
+	value := strings.TrimSuffix(string(ReadAllStdin()), "\n")
+	return strings.TrimSuffix(value, "\r")
+}
+
+// unescape replaces the escape sequences of a double quoted value
+func unescape(value string) (string, error) {
+	var b strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			b.WriteByte(value[i])
+			continue
+		}
+
+		i++
+		if i == len(value) {
+			return "", fmt.Errorf("trailing backslash")
+		}
+
+		switch value[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '\\', '"', '\'':
+			b.WriteByte(value[i])
+		default:
+			return "", fmt.Errorf("unknown escape sequence \\%c", value[i])
+		}
+	}
+
+	return b.String(), nil
+}
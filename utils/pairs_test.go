@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateEnvName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"API_KEY", true},
+		{"_private", true},
+		{"key2", true},
+		{"", false},
+		{"2KEY", false},
+		{"API-KEY", false},
+		{"API KEY", false},
+		{"tls.key", false},
+		{"KEY=", false},
+	}
+
+	for _, test := range tests {
+		err := ValidateEnvName(test.name)
+		if test.valid && err != nil {
+			t.Errorf("ValidateEnvName(%q) returned %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("ValidateEnvName(%q) did not return an error", test.name)
+		}
+	}
+}
+
+func TestParsePair(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    Pair
+		wantErr bool
+	}{
+		{arg: "KEY=value", want: Pair{Key: "KEY", Value: "value"}},
+		{arg: "KEY=", want: Pair{Key: "KEY", Value: ""}},
+		{arg: "KEY=a=b=c", want: Pair{Key: "KEY", Value: "a=b=c"}},
+		{arg: " KEY =value", want: Pair{Key: "KEY", Value: "value"}},
+		{arg: "KEY= padded ", want: Pair{Key: "KEY", Value: " padded "}},
+		{arg: "KEY=x", want: Pair{Key: "KEY", Value: "x"}},
+		{arg: "KEY=@secret.txt", want: Pair{Key: "KEY", Value: "@secret.txt"}},
+		{arg: "KEY=-", want: Pair{Key: "KEY", Value: "-"}},
+
+		{arg: "KEY='single quoted'", want: Pair{Key: "KEY", Value: "single quoted", Quoted: true}},
+		{arg: `KEY='no \n escapes'`, want: Pair{Key: "KEY", Value: `no \n escapes`, Quoted: true}},
+		{arg: "KEY=''", want: Pair{Key: "KEY", Value: "", Quoted: true}},
+		{arg: "KEY='@literal'", want: Pair{Key: "KEY", Value: "@literal", Quoted: true}},
+		{arg: "KEY='-'", want: Pair{Key: "KEY", Value: "-", Quoted: true}},
+		{arg: "KEY='a=b'", want: Pair{Key: "KEY", Value: "a=b", Quoted: true}},
+
+		{arg: `KEY="double quoted"`, want: Pair{Key: "KEY", Value: "double quoted", Quoted: true}},
+		{arg: `KEY=""`, want: Pair{Key: "KEY", Value: "", Quoted: true}},
+		{arg: `KEY="line one\nline two"`, want: Pair{Key: "KEY", Value: "line one\nline two", Quoted: true}},
+		{arg: `KEY="\r\t"`, want: Pair{Key: "KEY", Value: "\r\t", Quoted: true}},
+		{arg: `KEY="back\\slash"`, want: Pair{Key: "KEY", Value: `back\slash`, Quoted: true}},
+		{arg: `KEY="say \"hi\""`, want: Pair{Key: "KEY", Value: `say "hi"`, Quoted: true}},
+		{arg: `KEY="it\'s"`, want: Pair{Key: "KEY", Value: "it's", Quoted: true}},
+		{arg: `KEY="-"`, want: Pair{Key: "KEY", Value: "-", Quoted: true}},
+
+		{arg: `KEY="unterminated`, want: Pair{Key: "KEY", Value: `"unterminated`}},
+		{arg: `KEY='mixed"`, want: Pair{Key: "KEY", Value: `'mixed"`}},
+		{arg: `KEY="`, want: Pair{Key: "KEY", Value: `"`}},
+
+		{arg: `KEY="bad \x escape"`, wantErr: true},
+		{arg: `KEY="trailing \"`, wantErr: true},
+		{arg: "KEY", wantErr: true},
+		{arg: "=value", wantErr: true},
+		{arg: "2KEY=value", wantErr: true},
+		{arg: "API-KEY=value", wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := ParsePair(test.arg)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ParsePair(%q) = %+v, want an error", test.arg, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ParsePair(%q) returned %s", test.arg, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParsePair(%q) = %+v, want %+v", test.arg, got, test.want)
+		}
+	}
+}
+
+func TestPairResolveFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gvault-pairs-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "secret.txt")
+	if err := ioutil.WriteFile(path, []byte("from file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := Pair{Key: "KEY", Value: "@" + path}.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "from file\n" {
+		t.Errorf("got %q, want the file contents", value)
+	}
+
+	value, err = Pair{Key: "KEY", Value: "@" + path, Quoted: true}.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "@"+path {
+		t.Errorf("quoted value was read from a file: %q", value)
+	}
+
+	if _, err := (Pair{Key: "KEY", Value: "@" + filepath.Join(dir, "missing")}).Resolve(); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestPairResolveStdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+
+	w.WriteString("from stdin\r\n")
+	w.Close()
+
+	pair := Pair{Key: "KEY", Value: "-"}
+	if !pair.ReadsStdin() {
+		t.Fatal("expected - to read from stdin")
+	}
+
+	value, err := pair.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "from stdin" {
+		t.Errorf("got %q, want the trailing newline removed", value)
+	}
+
+	if (Pair{Key: "KEY", Value: "-", Quoted: true}).ReadsStdin() {
+		t.Error("a quoted - must not read from stdin")
+	}
+}
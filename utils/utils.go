@@ -1,8 +1,12 @@
 package utils
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/chzyer/readline"
 )
@@ -25,8 +29,70 @@ func Ask(question string, rl *readline.Instance) (string, error) {
 	return rl.Readline()
 }
 
+// AskPassword asks a question without echoing the answer
+func AskPassword(question string, rl *readline.Instance) (string, error) {
+	answer, err := rl.ReadPassword(question)
+	return string(answer), err
+}
+
+// IsTerminal checks if stdin is attached to a terminal and the user can be prompted
+func IsTerminal() bool {
+	return readline.IsTerminal(int(os.Stdin.Fd()))
+}
+
 // ReadAllStdin reads all data from stdin
 func ReadAllStdin() []byte {
 	bytes, _ := ioutil.ReadAll(os.Stdin)
 	return bytes
 }
+
+// Confirm asks a yes / no question, anything but y or yes is treated as no
+func Confirm(question string, rl *readline.Instance) bool {
+	answer, err := Ask(question+" [y/N]: ", rl)
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// Choose prints a numbered list of choices and asks for one of them.
+// The answer can either be the number of a choice or any free text value
+func Choose(question string, choices []string, rl *readline.Instance) (string, error) {
+	for i, choice := range choices {
+		fmt.Fprintf(rl.Stdout(), "  %d) %s\n", i+1, choice)
+	}
+
+	answer, err := Ask(question, rl)
+	if err != nil {
+		return "", err
+	}
+
+	answer = strings.TrimSpace(answer)
+	if i, convErr := strconv.Atoi(answer); convErr == nil && i >= 1 && i <= len(choices) {
+		return choices[i-1], nil
+	}
+	return answer, nil
+}
+
+// ParseDuration parses a duration like time.ParseDuration with additional support for days e.g. 90d
+func ParseDuration(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("%s is not a valid duration", value)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// Contains checks if value is one of the items
+func Contains(items []string, value string) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package vault
+
+import (
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// DefaultConcurrency the number of crypter calls a vault runs in parallel by default
+const DefaultConcurrency = 10
+
+// batchFunc transforms the value stored under key, e.g. by encrypting or decrypting it
+type batchFunc func(ctx context.Context, key, value string) (string, error)
+
+type batchResult struct {
+	key   string
+	value string
+	err   error
+}
+
+// batch runs fn for every entry in items using a bounded pool of workers.
+// Every failure is collected into a MultiError and no results are returned unless
+// all entries succeeded. Entries not yet started when ctx is done fail with its error
+func (v *Vault) batch(ctx context.Context, items map[string]string, fn batchFunc) (map[string]string, error) {
+	workers := v.Concurrency
+	if workers <= 0 {
+		workers = DefaultConcurrency
+	}
+
+	if workers > len(items) {
+		workers = len(items)
+	}
+
+	jobs := make(chan string)
+	results := make(chan batchResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range jobs {
+				if err := ctx.Err(); err != nil {
+					results <- batchResult{key: key, err: err}
+					continue
+				}
+				value, err := fn(ctx, key, items[key])
+				results <- batchResult{key: key, value: value, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for key := range items {
+			jobs <- key
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	output := map[string]string{}
+	errs := MultiError{}
+
+	for result := range results {
+		if result.err != nil {
+			errs[result.key] = result.err
+			continue
+		}
+		output[result.key] = result.value
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return output, nil
+}
@@ -0,0 +1,197 @@
+package vault
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+func TestBatchCollectsEveryFailure(t *testing.T) {
+	v := &Vault{Concurrency: 4}
+	items := map[string]string{"A": "a", "B": "b", "C": "c", "D": "d"}
+
+	results, err := v.batch(context.Background(), items, func(ctx context.Context, key, value string) (string, error) {
+		if key == "B" || key == "D" {
+			return "", fmt.Errorf("%s failed", key)
+		}
+		return value + "!", nil
+	})
+
+	if results != nil {
+		t.Errorf("expected no results on partial failure, got %v", results)
+	}
+
+	multi, ok := err.(MultiError)
+	if !ok {
+		t.Fatalf("expected a MultiError, got %T: %v", err, err)
+	}
+	if keys := multi.Keys(); !reflect.DeepEqual(keys, []string{"B", "D"}) {
+		t.Errorf("got failed keys %v, want [B D]", keys)
+	}
+	if multi["B"].Error() != "B failed" {
+		t.Errorf("got %q for B", multi["B"])
+	}
+}
+
+func TestBatchReturnsEveryResult(t *testing.T) {
+	v := &Vault{Concurrency: 3}
+	items := map[string]string{}
+	want := map[string]string{}
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("KEY_%d", i)
+		items[key] = key
+		want[key] = key + "!"
+	}
+
+	results, err := v.batch(context.Background(), items, func(ctx context.Context, key, value string) (string, error) {
+		return value + "!", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("got %v, want %v", results, want)
+	}
+}
+
+func TestBatchEmpty(t *testing.T) {
+	v := &Vault{}
+	results, err := v.batch(context.Background(), map[string]string{}, func(ctx context.Context, key, value string) (string, error) {
+		t.Error("fn called for an empty batch")
+		return "", nil
+	})
+	if err != nil || len(results) != 0 {
+		t.Fatalf("got %v, %v for an empty batch", results, err)
+	}
+}
+
+// peakConcurrency runs a batch of n slow calls and returns how many ran in parallel at most
+func peakConcurrency(t *testing.T, v *Vault, n int) int {
+	items := map[string]string{}
+	for i := 0; i < n; i++ {
+		items[fmt.Sprintf("KEY_%d", i)] = ""
+	}
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	_, err := v.batch(context.Background(), items, func(ctx context.Context, key, value string) (string, error) {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+
+		time.Sleep(2 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return value, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return peak
+}
+
+func TestBatchRespectsConcurrency(t *testing.T) {
+	for _, concurrency := range []int{1, 3, 8} {
+		peak := peakConcurrency(t, &Vault{Concurrency: concurrency}, 40)
+		if peak > concurrency {
+			t.Errorf("%d calls ran in parallel, the limit is %d", peak, concurrency)
+		}
+		if concurrency > 1 && peak < 2 {
+			t.Errorf("calls never ran in parallel with a limit of %d", concurrency)
+		}
+	}
+}
+
+func TestBatchDefaultConcurrency(t *testing.T) {
+	if peak := peakConcurrency(t, &Vault{}, DefaultConcurrency*3); peak > DefaultConcurrency {
+		t.Errorf("%d calls ran in parallel, the default limit is %d", peak, DefaultConcurrency)
+	}
+}
+
+func TestBatchStopsWhenContextIsDone(t *testing.T) {
+	v := &Vault{Concurrency: 1}
+	items := map[string]string{}
+	for i := 0; i < 10; i++ {
+		items[fmt.Sprintf("KEY_%d", i)] = ""
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls int32
+	_, err := v.batch(ctx, items, func(ctx context.Context, key, value string) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		cancel()
+		return value, nil
+	})
+
+	if calls != 1 {
+		t.Errorf("fn was called %d times after the context was cancelled", calls)
+	}
+
+	multi, ok := err.(MultiError)
+	if !ok {
+		t.Fatalf("expected a MultiError, got %T: %v", err, err)
+	}
+	if len(multi) != len(items)-1 {
+		t.Errorf("got %d failures, want %d", len(multi), len(items)-1)
+	}
+	for key, keyErr := range multi {
+		if keyErr != context.Canceled {
+			t.Errorf("%s failed with %v, want context.Canceled", key, keyErr)
+		}
+	}
+}
+
+func TestDecryptAllPartialFailureKeepsSecrets(t *testing.T) {
+	defer chdirTemp(t)()
+
+	v := newLocalVault(t, "main")
+	setSecrets(t, v, map[string]string{"A": "a", "B": "b", "C": "c"})
+
+	// a cipher text moved to another secret fails its additional authenticated data check
+	v.Secrets["B"] = v.Secrets["C"]
+	before := copySecrets(v)
+
+	err := v.DecryptAll(context.Background())
+	multi, ok := errors.Cause(err).(MultiError)
+	if !ok {
+		t.Fatalf("expected a MultiError cause, got %T: %v", errors.Cause(err), err)
+	}
+	if keys := multi.Keys(); !reflect.DeepEqual(keys, []string{"B"}) {
+		t.Errorf("got failed keys %v, want [B]", keys)
+	}
+	if !reflect.DeepEqual(v.Secrets, before) {
+		t.Error("secrets were modified although decrypting failed")
+	}
+}
+
+func TestRotatePartialFailureKeepsSecrets(t *testing.T) {
+	defer chdirTemp(t)()
+
+	v := newLocalVault(t, "main")
+	setSecrets(t, v, map[string]string{"A": "a", "B": "b"})
+	v.Secrets["A"] = "not a cipher text"
+	before := copySecrets(v)
+
+	if err := v.Rotate(context.Background(), "", "", ""); err == nil {
+		t.Fatal("expected rotating a corrupted vault to fail")
+	} else if _, ok := errors.Cause(err).(MultiError); !ok {
+		t.Fatalf("expected a MultiError cause, got %T: %v", errors.Cause(err), err)
+	}
+
+	if !reflect.DeepEqual(v.Secrets, before) {
+		t.Error("secrets were modified although rotating failed")
+	}
+}
@@ -0,0 +1,28 @@
+package vault
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MultiError collects the errors of a batch operation keyed by secret name
+type MultiError map[string]error
+
+// Keys returns the names of the secrets that failed in sorted order
+func (m MultiError) Keys() []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (m MultiError) Error() string {
+	lines := []string{fmt.Sprintf("%d secret(s) failed:", len(m))}
+	for _, key := range m.Keys() {
+		lines = append(lines, fmt.Sprintf("  %s: %s", key, m[key]))
+	}
+	return strings.Join(lines, "\n")
+}
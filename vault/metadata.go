@@ -0,0 +1,135 @@
+package vault
+
+import (
+	"encoding/base64"
+	"os"
+	"os/user"
+	"sort"
+	"time"
+
+	"github.com/sourcec0de/gvault/crypter"
+	"golang.org/x/net/context"
+)
+
+// SecretMetadata describes a secret. It is stored next to the cipher texts in plain text
+// and is not part of the secrets hash
+type SecretMetadata struct {
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	UpdatedBy   string    `json:"updatedBy,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Owner       string    `json:"owner,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	// Size of the plain text in bytes, unknown for secrets added by older versions
+	Size *int `json:"size,omitempty"`
+	// Type is SecretTypeFile for secrets added from a file and empty otherwise
+	Type string `json:"type,omitempty"`
+	// Mode the permissions of the file a secret was added from
+	Mode os.FileMode `json:"mode,omitempty"`
+	// Binary secrets are not valid UTF-8 and are base64 encoded in text exports
+	Binary bool `json:"binary,omitempty"`
+}
+
+// copy returns a deep copy of the metadata
+func (m *SecretMetadata) copy() *SecretMetadata {
+	copied := *m
+	copied.Tags = append([]string{}, m.Tags...)
+	return &copied
+}
+
+// SecretTypeFile the type of secrets added from a file
+const SecretTypeFile = "file"
+
+// SecretMetadata returns the metadata of a secret, creating it if the secret has none
+// returns nil if the secret does not exist
+func (v *Vault) SecretMetadata(key string) *SecretMetadata {
+	if _, ok := v.Secrets[key]; !ok {
+		return nil
+	}
+
+	if v.Metadata == nil {
+		v.Metadata = map[string]*SecretMetadata{}
+	}
+
+	metadata, ok := v.Metadata[key]
+	if !ok {
+		metadata = &SecretMetadata{}
+		v.Metadata[key] = metadata
+	}
+	return metadata
+}
+
+// SecretNames returns the sorted names of all secrets in the vault
+func (v *Vault) SecretNames() []string {
+	names := []string{}
+	for name := range v.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// touchSecret records that a secret was changed by the current identity
+func (v *Vault) touchSecret(ctx context.Context, key string, size int) {
+	metadata := v.SecretMetadata(key)
+	if metadata == nil {
+		return
+	}
+
+	now := time.Now().UTC()
+	if metadata.CreatedAt.IsZero() {
+		metadata.CreatedAt = now
+	}
+	metadata.UpdatedAt = now
+	metadata.UpdatedBy = v.identity(ctx)
+	metadata.Size = &size
+
+	metadata.Type = ""
+	metadata.Mode = 0
+	metadata.Binary = false
+}
+
+// IsBinary reports whether a secret was marked as binary
+func (v *Vault) IsBinary(key string) bool {
+	metadata, ok := v.Metadata[key]
+	return ok && metadata.Binary
+}
+
+// TextSecrets returns the decrypted secrets with binary secrets base64 encoded
+// for formats that can only hold text
+func (v *Vault) TextSecrets() map[string]string {
+	if !v.decrypted {
+		return v.Secrets
+	}
+
+	secrets := map[string]string{}
+	for key, value := range v.Secrets {
+		if v.IsBinary(key) {
+			value = base64.StdEncoding.EncodeToString([]byte(value))
+		}
+		secrets[key] = value
+	}
+	return secrets
+}
+
+// identity the name recorded as author of changes. The Google identity is used for
+// KMS backed vaults and the operating system user otherwise or if it can not be determined
+func (v *Vault) identity(ctx context.Context) string {
+	if v.author != "" {
+		return v.author
+	}
+
+	if v.Backend != crypter.BackendLocal {
+		if email, err := crypter.Identity(ctx, v.crypterOptions()); err == nil {
+			v.author = email
+			return v.author
+		}
+	}
+
+	if current, err := user.Current(); err == nil {
+		v.author = current.Username
+	} else {
+		v.author = os.Getenv("USER")
+	}
+	return v.author
+}
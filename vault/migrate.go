@@ -0,0 +1,107 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// FormatVersion the vault file format written by this version of gvault
+const FormatVersion = 5
+
+// Migration upgrades the raw JSON of a vault file by a single format version
+type Migration func(raw map[string]interface{}) error
+
+// migrations maps a format version to the migration upgrading it to the next one
+var migrations = map[int]Migration{
+	0: migrateLegacy,
+	1: addOptionalFields, // 2: per secret metadata
+	2: addOptionalFields, // 3: secret tags and sizes
+	3: addOptionalFields, // 4: file and binary secrets
+	4: addOptionalFields, // 5: vaults keeping secrets Cloud Build can decrypt
+}
+
+// migrateLegacy upgrades files written before the format was versioned
+func migrateLegacy(raw map[string]interface{}) error {
+	if raw["secrets"] == nil {
+		raw["secrets"] = map[string]interface{}{}
+	}
+	return nil
+}
+
+// addOptionalFields upgrades to a format version that only added optional fields, older
+// files simply lack them. The version is still bumped so older binaries refuse to load
+// the file instead of dropping the fields when saving
+func addOptionalFields(raw map[string]interface{}) error {
+	return nil
+}
+
+// formatVersion reads the format version of a raw vault file
+func formatVersion(raw map[string]interface{}) (int, error) {
+	value, ok := raw["formatVersion"]
+	if !ok || value == nil {
+		return 0, nil
+	}
+
+	number, ok := value.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("invalid formatVersion %v", value)
+	}
+
+	version, err := number.Int64()
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid formatVersion")
+	}
+
+	return int(version), nil
+}
+
+// migrate upgrades the raw JSON of a vault file to FormatVersion and returns the
+// version it was written with. Files written by a newer gvault are rejected
+func migrate(data []byte) ([]byte, int, error) {
+	raw := map[string]interface{}{}
+
+	// numbers are kept as written so the secrets hash does not lose precision
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, 0, err
+	}
+
+	from, err := formatVersion(raw)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if from > FormatVersion {
+		return nil, from, fmt.Errorf("vault format version %d is newer than the supported version %d, please upgrade gvault", from, FormatVersion)
+	}
+
+	if from == FormatVersion {
+		return data, from, nil
+	}
+
+	for version := from; version < FormatVersion; version++ {
+		migration, ok := migrations[version]
+		if !ok {
+			return nil, from, fmt.Errorf("no migration from vault format version %d", version)
+		}
+
+		if err := migration(raw); err != nil {
+			return nil, from, errors.Wrapf(err, "failed to migrate vault from format version %d", version)
+		}
+	}
+
+	raw["formatVersion"] = FormatVersion
+
+	migrated, err := json.Marshal(raw)
+	return migrated, from, err
+}
+
+// Migrated reports whether the vault was loaded from an older format
+// and has to be saved to persist the migration
+func (v *Vault) Migrated() bool {
+	return v.loaded && v.loadedFormat < FormatVersion
+}
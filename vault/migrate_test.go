@@ -0,0 +1,128 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateEveryFormatVersion(t *testing.T) {
+	for from := 0; from <= FormatVersion; from++ {
+		t.Run(fmt.Sprintf("from %d", from), func(t *testing.T) {
+			raw := map[string]interface{}{
+				"name":    "main",
+				"secrets": map[string]string{"API_KEY": "cipher"},
+				"version": uint64(18446744073709551615),
+			}
+			if from > 0 {
+				raw["formatVersion"] = from
+			}
+			data, err := json.Marshal(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			migrated, version, err := migrate(data)
+			if err != nil {
+				t.Fatalf("migrate: %s", err)
+			}
+			if version != from {
+				t.Errorf("got loaded version %d, want %d", version, from)
+			}
+
+			var v Vault
+			if err := json.Unmarshal(migrated, &v); err != nil {
+				t.Fatal(err)
+			}
+			if v.FormatVersion != FormatVersion {
+				t.Errorf("got format version %d, want %d", v.FormatVersion, FormatVersion)
+			}
+			if v.Secrets["API_KEY"] != "cipher" {
+				t.Errorf("secrets were not kept: %v", v.Secrets)
+			}
+			if v.Version != 18446744073709551615 {
+				t.Errorf("version lost precision: %d", v.Version)
+			}
+		})
+	}
+}
+
+func TestMigrateLegacyWithoutSecrets(t *testing.T) {
+	migrated, _, err := migrate([]byte(`{"name":"main"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v Vault
+	if err := json.Unmarshal(migrated, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Secrets == nil {
+		t.Error("legacy vault without secrets was not given an empty secrets map")
+	}
+}
+
+func TestMigrateEveryVersionHasAMigration(t *testing.T) {
+	for version := 0; version < FormatVersion; version++ {
+		if migrations[version] == nil {
+			t.Errorf("no migration from format version %d", version)
+		}
+	}
+}
+
+func TestMigrateRejectsNewerVersion(t *testing.T) {
+	data := []byte(fmt.Sprintf(`{"name":"main","formatVersion":%d,"secrets":{}}`, FormatVersion+1))
+
+	if _, _, err := migrate(data); err == nil || !strings.Contains(err.Error(), "please upgrade gvault") {
+		t.Fatalf("expected newer format version error, got %v", err)
+	}
+}
+
+func TestMigrateRejectsInvalidVersion(t *testing.T) {
+	if _, _, err := migrate([]byte(`{"formatVersion":"two"}`)); err == nil {
+		t.Fatal("expected an error for a non numeric format version")
+	}
+}
+
+func TestLoadNewerVersionFails(t *testing.T) {
+	defer chdirTemp(t)()
+
+	if err := os.MkdirAll(gvaultFolder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(fmt.Sprintf(`{"name":"main","formatVersion":%d,"secrets":{}}`, FormatVersion+1))
+	if err := ioutil.WriteFile(filepath.Join(gvaultFolder, "main.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	v := New(Config{Name: "main"})
+	if err := v.Load(); err == nil {
+		t.Fatal("expected loading a newer vault format to fail")
+	}
+}
+
+func TestLoadMigratesOlderVersion(t *testing.T) {
+	defer chdirTemp(t)()
+
+	if err := os.MkdirAll(gvaultFolder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(gvaultFolder, "main.json"), []byte(`{"name":"main"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	v := New(Config{Name: "main"})
+	if err := v.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if !v.Migrated() {
+		t.Error("expected a legacy vault to be reported as migrated")
+	}
+	if v.FormatVersion != FormatVersion {
+		t.Errorf("got format version %d, want %d", v.FormatVersion, FormatVersion)
+	}
+}
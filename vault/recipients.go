@@ -0,0 +1,164 @@
+package vault
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/sourcec0de/gvault/crypter"
+	"golang.org/x/net/context"
+)
+
+// Recipient an additional key that can unwrap the vault data key
+// e.g. a break-glass key in a different project or region
+type Recipient struct {
+	Backend  string `json:"backend,omitempty"`
+	Project  string `json:"project,omitempty"`
+	Location string `json:"location,omitempty"`
+	Keyring  string `json:"keyring,omitempty"`
+	Key      string `json:"key"`
+	DataKey  string `json:"dataKey"`
+	crypter  crypter.Crypter
+	options  crypter.Options
+}
+
+// Name a unique name identifying the recipient key
+func (r *Recipient) Name() string {
+	if r.Backend == crypter.BackendLocal {
+		return crypter.BackendLocal + ":" + r.Key
+	}
+	return crypter.KmsKeyName(r.Project, r.Location, r.Keyring, r.Key)
+}
+
+// Encrypt encrypts using the recipient key, initializing its crypter on first use
+func (r *Recipient) Encrypt(ctx context.Context, plainText, aad []byte) (string, error) {
+	c, err := r.initCrypter()
+	if err != nil {
+		return "", err
+	}
+	return c.Encrypt(ctx, plainText, aad)
+}
+
+// Decrypt decrypts using the recipient key, initializing its crypter on first use
+func (r *Recipient) Decrypt(ctx context.Context, cipherText string, aad []byte) ([]byte, error) {
+	c, err := r.initCrypter()
+	if err != nil {
+		return nil, err
+	}
+	return c.Decrypt(ctx, cipherText, aad)
+}
+
+func (r *Recipient) initCrypter() (crypter.Crypter, error) {
+	if r.crypter != nil {
+		return r.crypter, nil
+	}
+
+	newCrypter, err := crypter.New(crypter.Config{
+		Backend:  r.Backend,
+		Project:  &r.Project,
+		Location: &r.Location,
+		Keyring:  &r.Keyring,
+		Key:      &r.Key,
+		Options:  r.options,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to initialize crypter for %s", r.Name())
+	}
+
+	r.crypter = newCrypter
+	return r.crypter, nil
+}
+
+// KeyName a unique name identifying the primary key of the vault
+func (v *Vault) KeyName() string {
+	primary := &Recipient{
+		Backend:  v.Backend,
+		Project:  v.Project,
+		Location: v.Location,
+		Keyring:  v.Keyring,
+		Key:      v.Key,
+	}
+	return primary.Name()
+}
+
+// wrappedDataKeys every wrapped copy of the data key in the order they should be tried
+func (v *Vault) wrappedDataKeys() []crypter.WrappedDataKey {
+	wrappedKeys := []crypter.WrappedDataKey{{Decrypter: v.Crypter, CipherText: v.DataKey}}
+	for _, recipient := range v.Recipients {
+		wrappedKeys = append(wrappedKeys, crypter.WrappedDataKey{
+			Decrypter:  recipient,
+			CipherText: recipient.DataKey,
+		})
+	}
+	return wrappedKeys
+}
+
+// AddRecipient wraps the vault data key for an additional key
+func (v *Vault) AddRecipient(ctx context.Context, recipient *Recipient) error {
+	if v.DataKey == "" {
+		return fmt.Errorf("vault (%s) must use envelope encryption to add keys. Run `gvault upgrade` first", v.Name)
+	}
+
+	if recipient.Backend == crypter.BackendKMSAsymmetric {
+		return fmt.Errorf("asymmetric keys can not be added as additional recipients")
+	}
+
+	if recipient.Name() == v.KeyName() {
+		return fmt.Errorf("%s is already the primary key of this vault", recipient.Name())
+	}
+
+	for _, existing := range v.Recipients {
+		if existing.Name() == recipient.Name() {
+			return fmt.Errorf("%s is already a recipient of this vault", recipient.Name())
+		}
+	}
+
+	recipient.options = v.crypterOptions()
+
+	secretCrypter, err := v.secretCrypter()
+	if err != nil {
+		return err
+	}
+
+	envelope, ok := secretCrypter.(*crypter.Envelope)
+	if !ok {
+		return fmt.Errorf("vault (%s) does not use envelope encryption", v.Name)
+	}
+
+	wrappedKey, err := envelope.Wrap(ctx, recipient)
+	if err != nil {
+		return err
+	}
+
+	recipient.DataKey = wrappedKey
+	v.Recipients = append(v.Recipients, recipient)
+	return nil
+}
+
+// RemoveRecipient removes a key from the vault. A new data key is generated and every
+// secret re-encrypted so the removed key can not decrypt future versions of the vault
+func (v *Vault) RemoveRecipient(ctx context.Context, name string) error {
+	if name == v.KeyName() {
+		return fmt.Errorf("%s is the primary key of this vault. Use `gvault rotate` to change it", name)
+	}
+
+	recipients := []*Recipient{}
+	for _, recipient := range v.Recipients {
+		if recipient.Name() != name {
+			recipients = append(recipients, recipient)
+		}
+	}
+
+	if len(recipients) == len(v.Recipients) {
+		return fmt.Errorf("%s is not a recipient of this vault", name)
+	}
+
+	oldRecipients := v.Recipients
+	v.Recipients = recipients
+
+	if err := v.Rotate(ctx, "", "", ""); err != nil {
+		v.Recipients = oldRecipients
+		return err
+	}
+
+	return nil
+}
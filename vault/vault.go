@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/ghodss/yaml"
 	"github.com/joho/godotenv"
@@ -16,6 +17,7 @@ import (
 	"github.com/sourcec0de/gvault/crypter"
 	"github.com/sourcec0de/gvault/utils"
 	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
 )
 
 var (
@@ -32,17 +34,35 @@ When initializing a new vault you must supply
 
 // Vault a vault that stores in a json format
 type Vault struct {
-	Name      string            `json:"-"`
-	Version   uint64            `json:"version"`
-	Secrets   map[string]string `json:"secrets"`
-	Project   string            `json:"project"`
-	Keyring   string            `json:"keyring"`
-	Location  string            `json:"location"`
-	Key       string            `json:"key"`
-	Crypter   *crypter.Crypter  `json:"-"`
-	isNew     bool
-	loaded    bool
-	decrypted bool
+	Name          string                     `json:"-"`
+	FormatVersion int                        `json:"formatVersion"`
+	Version       uint64                     `json:"version"`
+	Secrets       map[string]string          `json:"secrets"`
+	Metadata      map[string]*SecretMetadata `json:"metadata,omitempty"`
+	Project       string                     `json:"project"`
+	Keyring       string                     `json:"keyring"`
+	Location      string                     `json:"location"`
+	Key           string                     `json:"key"`
+	KeyVersion    string                     `json:"keyVersion,omitempty"`
+	PublicKey     string                     `json:"publicKey,omitempty"`
+	Backend       string                     `json:"backend,omitempty"`
+	DataKey       string                     `json:"dataKey,omitempty"`
+	AAD           bool                       `json:"aad,omitempty"`
+	CloudBuild    bool                       `json:"cloudBuild,omitempty"`
+	Recipients    []*Recipient               `json:"recipients,omitempty"`
+	// CredentialsFile and ImpersonateServiceAccount select the identity used for this vault
+	CredentialsFile           string          `json:"credentialsFile,omitempty"`
+	ImpersonateServiceAccount string          `json:"impersonateServiceAccount,omitempty"`
+	Crypter                   crypter.Crypter `json:"-"`
+	Concurrency               int             `json:"-"`
+	Options                   crypter.Options `json:"-"`
+	DefaultOptions            crypter.Options `json:"-"`
+	envelope                  *crypter.Envelope
+	isNew                     bool
+	loaded                    bool
+	decrypted                 bool
+	loadedFormat              int
+	author                    string
 }
 
 // Config a config for initializing a vault
@@ -52,6 +72,7 @@ type Config struct {
 	Keyring  string
 	Location string
 	Key      string
+	Backend  string
 }
 
 // Path returns the path of the current vault instance
@@ -59,59 +80,171 @@ func (v *Vault) Path() string {
 	return fmt.Sprintf(filepath.Join(utils.CWD(), gvaultFolder, v.Name+".json"))
 }
 
+// List returns the names of all vaults in the current directory
+func List() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(utils.CWD(), gvaultFolder, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, path := range paths {
+		names = append(names, strings.TrimSuffix(filepath.Base(path), ".json"))
+	}
+	return names, nil
+}
+
 // SetSecret add a secret to the vault
-func (v *Vault) SetSecret(key, value string) error {
-	encValue, err := v.Crypter.Encrypt([]byte(value))
+func (v *Vault) SetSecret(ctx context.Context, key, value string) error {
+	return v.SetSecretBytes(ctx, key, []byte(value))
+}
+
+// SetSecretBytes add a secret to the vault, values that are not valid UTF-8 are marked as binary
+func (v *Vault) SetSecretBytes(ctx context.Context, key string, value []byte) error {
+	secretCrypter, err := v.secretCrypter()
+	if err != nil {
+		return err
+	}
+
+	encValue, err := secretCrypter.Encrypt(ctx, value, v.secretAAD(key))
 	if err != nil {
 		return err
 	}
 	v.Secrets[key] = encValue
+	v.touchSecret(ctx, key, len(value))
+	v.Metadata[key].Binary = !utf8.Valid(value)
 	return nil
 }
 
-// RemoveSecret removes a secret from the vault
+// SetFileSecret add the contents of a file to the vault, keeping its permissions
+func (v *Vault) SetFileSecret(ctx context.Context, key, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	value, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := v.SetSecretBytes(ctx, key, value); err != nil {
+		return err
+	}
+
+	v.Metadata[key].Type = SecretTypeFile
+	v.Metadata[key].Mode = info.Mode().Perm()
+	return nil
+}
+
+// RemoveSecret removes a secret and its metadata from the vault
 func (v *Vault) RemoveSecret(key string) {
 	delete(v.Secrets, key)
+	delete(v.Metadata, key)
+}
+
+// CopySecret copies a secret to dst under a new name. The secret is decrypted with the key
+// of this vault and encrypted with the key of dst, its metadata is preserved
+func (v *Vault) CopySecret(ctx context.Context, key string, dst *Vault, newKey string) error {
+	value, err := v.GetSecretBytes(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if err := dst.SetSecretBytes(ctx, newKey, value); err != nil {
+		return errors.Wrapf(err, "failed to encrypt %s in %s", newKey, dst.Name)
+	}
+
+	if metadata, ok := v.Metadata[key]; ok {
+		dst.Metadata[newKey] = metadata.copy()
+	}
+
+	return nil
+}
+
+// CopyAll copies every secret to dst, encrypting them with the key of dst
+// their metadata is preserved
+func (v *Vault) CopyAll(ctx context.Context, dst *Vault) error {
+	plainTexts, err := v.DecryptedSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := dst.EncryptEnvMap(ctx, plainTexts)
+	if err != nil {
+		return err
+	}
+
+	for key, cipherText := range encrypted {
+		dst.Secrets[key] = cipherText
+		dst.touchSecret(ctx, key, len(plainTexts[key]))
+
+		if metadata, ok := v.Metadata[key]; ok {
+			dst.Metadata[key] = metadata.copy()
+		}
+	}
+
+	return nil
+}
+
+// Delete removes the vault file
+func (v *Vault) Delete() error {
+	if err := os.Remove(v.Path()); err != nil {
+		return errors.Wrap(err, "failed to delete vault file")
+	}
+	v.loaded = false
+	return nil
 }
 
 // KmsKeyName name of the KMS resrouce
 func (v *Vault) KmsKeyName() string {
-	return v.Crypter.KmsKeyName()
+	return crypter.KmsKeyName(v.Project, v.Location, v.Keyring, v.Key)
 }
 
 // GetSecret gets a secret from the vault
-func (v *Vault) GetSecret(key string) (string, error) {
+func (v *Vault) GetSecret(ctx context.Context, key string) (string, error) {
+	secretBytes, err := v.GetSecretBytes(ctx, key)
+	return string(secretBytes), err
+}
+
+// GetSecretBytes gets the exact bytes of a secret from the vault
+func (v *Vault) GetSecretBytes(ctx context.Context, key string) ([]byte, error) {
 
 	cipherText := v.Secrets[key]
 
 	if cipherText == "" {
-		return "", fmt.Errorf("No secret by that name")
+		return nil, fmt.Errorf("No secret by that name")
 	}
 
-	secretBytes, err := v.Crypter.Decrypt(cipherText)
+	secretCrypter, err := v.secretCrypter()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return string(secretBytes), nil
+	secretBytes, err := secretCrypter.Decrypt(ctx, cipherText, v.secretAAD(key))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decrypt %s", key)
+	}
+
+	return secretBytes, nil
 }
 
 func (v *Vault) toJSON() ([]byte, error) {
-	return json.MarshalIndent(v.Secrets, "", "  ")
+	return json.MarshalIndent(v.TextSecrets(), "", "  ")
 }
 
 func (v *Vault) toYAML() ([]byte, error) {
-	return yaml.Marshal(v.Secrets)
+	return yaml.Marshal(v.TextSecrets())
 }
 
 func (v *Vault) toENV() ([]byte, error) {
-	env, err := godotenv.Marshal(v.Secrets)
+	env, err := godotenv.Marshal(v.TextSecrets())
 	return []byte(env), err
 }
 
 func (v *Vault) toSHELL() ([]byte, error) {
 	var output string
-	env, err := godotenv.Marshal(v.Secrets)
+	env, err := godotenv.Marshal(v.TextSecrets())
 	for _, line := range strings.Split(strings.TrimSuffix(env, "\n"), "\n") {
 		output += ("export " + line + "\n")
 	}
@@ -148,6 +281,7 @@ func (v *Vault) Save() error {
 	}
 
 	v.Version = version
+	v.FormatVersion = FormatVersion
 
 	bytes, jsonSaveErr := json.MarshalIndent(v, "", "  ")
 
@@ -156,7 +290,7 @@ func (v *Vault) Save() error {
 	}
 
 	if validationErr := v.validate(); validationErr != nil {
-		if v.isNew {
+		if !v.loaded {
 			return errors.Wrap(validationErr, validationErrMsg)
 		}
 		return validationErr
@@ -166,13 +300,45 @@ func (v *Vault) Save() error {
 		return createErr
 	}
 
-	if ioWriteErr := ioutil.WriteFile(v.Path(), bytes, os.ModePerm); ioWriteErr != nil {
+	if ioWriteErr := writeFileAtomic(v.Path(), bytes, 0644); ioWriteErr != nil {
 		return ioWriteErr
 	}
 
 	return nil
 }
 
+// writeFileAtomic writes data to a temporary file next to path and renames it into place
+// so a vault file is never left partially written
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary vault file")
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return errors.Wrap(err, "failed to write temporary vault file")
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return errors.Wrap(err, "failed to replace vault file")
+	}
+
+	return nil
+}
+
 // HashSecrets generates a unique hash of the encrypted secrets
 // this is indended to be used as a version when syncronizing this with a secret store
 // like kubernetes secrets
@@ -187,86 +353,81 @@ func (v *Vault) Load() error {
 		return errors.Wrap(ioReadErr, "failed to read vault file")
 	}
 
+	bytes, format, migrateErr := migrate(bytes)
+	if migrateErr != nil {
+		return errors.Wrapf(migrateErr, "failed to load %s", v.Path())
+	}
+
 	if unmarshalErr := json.Unmarshal(bytes, v); unmarshalErr != nil {
 		return errors.Wrap(unmarshalErr, "failed to unmarshal vault JSON")
 	}
 
 	v.loaded = true
+	v.loadedFormat = format
 	return nil
 }
 
-type kmsAPIResult struct {
-	key   string
-	value string
-}
-
 // DecryptAll decrypts all secrets in this vault
-func (v *Vault) DecryptAll() error {
-	totalReq := 0
-	resultsChan := make(chan kmsAPIResult)
-
-	for name, cipherText := range v.Secrets {
-		totalReq++
-		go func(name, cipherText string) {
-			bytes, _ := v.Crypter.Decrypt(cipherText)
-			resultsChan <- kmsAPIResult{
-				key:   name,
-				value: string(bytes),
-			}
-		}(name, cipherText)
-	}
-
-	for {
-		result := <-resultsChan
-		v.Secrets[result.key] = result.value
-		totalReq--
-
-		if totalReq == 0 {
-			break
-		}
+// the secrets are only replaced with their plain text if every secret was decrypted
+func (v *Vault) DecryptAll(ctx context.Context) error {
+	plainTexts, err := v.DecryptedSecrets(ctx)
+	if err != nil {
+		return err
 	}
 
+	v.Secrets = plainTexts
 	v.decrypted = true
 
 	return nil
 }
 
-// EncryptEnvMap encrypts all secrets in a given envMap
-func (v *Vault) EncryptEnvMap(envMap map[string]string) (map[string]string, error) {
-	totalReq := 0
-	resultsChan := make(chan kmsAPIResult)
-
-	for key, plainText := range envMap {
-		totalReq++
-		go func(key, plainText string) {
-			bytes, _ := v.Crypter.Encrypt([]byte(plainText))
-			resultsChan <- kmsAPIResult{
-				key:   key,
-				value: string(bytes),
-			}
-		}(key, plainText)
+// DecryptedSecrets returns the plain text of all secrets without modifying the vault
+func (v *Vault) DecryptedSecrets(ctx context.Context) (map[string]string, error) {
+	secretCrypter, err := v.secretCrypter()
+	if err != nil {
+		return nil, err
+	}
+
+	plainTexts, err := v.batch(ctx, v.Secrets, func(ctx context.Context, name, cipherText string) (string, error) {
+		bytes, err := secretCrypter.Decrypt(ctx, cipherText, v.secretAAD(name))
+		return string(bytes), err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt vault")
 	}
 
-	encryptedEnvMap := map[string]string{}
+	return plainTexts, nil
+}
 
-	for {
-		result := <-resultsChan
-		encryptedEnvMap[result.key] = result.value
-		totalReq--
+// EncryptEnvMap encrypts all secrets in a given envMap
+func (v *Vault) EncryptEnvMap(ctx context.Context, envMap map[string]string) (map[string]string, error) {
+	secretCrypter, err := v.secretCrypter()
+	if err != nil {
+		return nil, err
+	}
 
-		if totalReq == 0 {
-			break
-		}
+	encryptedEnvMap, err := v.batch(ctx, envMap, func(ctx context.Context, key, plainText string) (string, error) {
+		return secretCrypter.Encrypt(ctx, []byte(plainText), v.secretAAD(key))
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encrypt secrets")
 	}
 
 	return encryptedEnvMap, nil
 }
 
-// MergeEncryptedEnvMap merges an encryptedEnvMap into the secrets
-func (v *Vault) MergeEncryptedEnvMap(encryptedEnvMap map[string]string) {
+// ImportEnvMap encrypts and adds all secrets in a given envMap
+func (v *Vault) ImportEnvMap(ctx context.Context, envMap map[string]string) error {
+	encryptedEnvMap, err := v.EncryptEnvMap(ctx, envMap)
+	if err != nil {
+		return err
+	}
+
 	for key, value := range encryptedEnvMap {
 		v.Secrets[key] = value
+		v.touchSecret(ctx, key, len(envMap[key]))
 	}
+	return nil
 }
 
 // Base64Encode encodes vault strings as base64 only useful when exporting to k8s
@@ -320,34 +481,314 @@ func (v *Vault) LoadOrCreate() error {
 	return v.Save()
 }
 
+// crypterOptions the crypter options with the identity settings of the vault applied.
+// Options take precedence over the settings stored in the vault, followed by DefaultOptions
+func (v *Vault) crypterOptions() crypter.Options {
+	options := v.Options
+
+	if options.CredentialsFile == "" {
+		options.CredentialsFile = v.CredentialsFile
+	}
+	if options.CredentialsFile == "" {
+		options.CredentialsFile = v.DefaultOptions.CredentialsFile
+	}
+
+	if options.ImpersonateServiceAccount == "" {
+		options.ImpersonateServiceAccount = v.ImpersonateServiceAccount
+	}
+	if options.ImpersonateServiceAccount == "" {
+		options.ImpersonateServiceAccount = v.DefaultOptions.ImpersonateServiceAccount
+	}
+
+	return options
+}
+
 // InitCrypter initialize the vaults crypter
 func (v *Vault) InitCrypter() error {
-	newCrypter, err := crypter.NewCrypter(&v.Project, &v.Location, &v.Keyring, &v.Key)
+	newCrypter, err := crypter.New(crypter.Config{
+		Backend:    v.Backend,
+		Project:    &v.Project,
+		Location:   &v.Location,
+		Keyring:    &v.Keyring,
+		Key:        &v.Key,
+		KeyVersion: &v.KeyVersion,
+		PublicKey:  &v.PublicKey,
+		Options:    v.crypterOptions(),
+	})
 	if err != nil {
 		return errors.Wrap(err, "failed to initialize vault crypter")
 	}
 	v.Crypter = newCrypter
+
+	for _, recipient := range v.Recipients {
+		recipient.options = v.crypterOptions()
+	}
+
+	return nil
+}
+
+// EnsureCrypter initializes the vault crypter on first use so commands that
+// never encrypt or decrypt do not require any credentials
+func (v *Vault) EnsureCrypter() error {
+	if v.Crypter != nil {
+		return nil
+	}
+	return v.InitCrypter()
+}
+
+// secretCrypter returns the crypter used for individual secrets
+// vaults with a data key encrypt secrets locally, older vaults use the backend directly
+func (v *Vault) secretCrypter() (crypter.Crypter, error) {
+	if err := v.EnsureCrypter(); err != nil {
+		return nil, err
+	}
+
+	if v.DataKey == "" {
+		return v.Crypter, nil
+	}
+
+	if v.envelope == nil || v.envelope.WrappedKey() != v.DataKey {
+		v.envelope = crypter.NewMultiEnvelope(v.wrappedDataKeys()...)
+	}
+
+	return v.envelope, nil
+}
+
+// secretAAD the additional authenticated data a secret is encrypted with
+// vaults created before names were bound to their cipher texts use none
+func (v *Vault) secretAAD(name string) []byte {
+	if !v.AAD {
+		return nil
+	}
+	return secretAAD(v.Name, name)
+}
+
+// secretAAD binds a cipher text to the vault and secret name it was stored under
+// so it can not be swapped with another secret or copied into another vault
+func secretAAD(vaultName, secretName string) []byte {
+	return []byte(vaultName + "\x00" + secretName)
+}
+
+// EnableEnvelopeEncryption generates a data key for the vault and re-encrypts
+// every existing secret with it. The vault is left untouched if any step fails
+func (v *Vault) EnableEnvelopeEncryption(ctx context.Context) error {
+	if v.DataKey != "" {
+		return fmt.Errorf("vault (%s) already uses envelope encryption", v.Name)
+	}
+
+	if v.CloudBuild {
+		return fmt.Errorf("vault (%s) keeps secrets Cloud Build can decrypt and can not use envelope encryption", v.Name)
+	}
+
+	if v.Backend == crypter.BackendKMSAsymmetric {
+		return fmt.Errorf("vaults using asymmetric keys encrypt locally and can not use envelope encryption")
+	}
+
+	if err := v.EnsureCrypter(); err != nil {
+		return err
+	}
+
+	envelope, err := crypter.NewDataKey(ctx, v.Crypter)
+	if err != nil {
+		return err
+	}
+
+	secrets, err := v.batch(ctx, v.Secrets, func(ctx context.Context, name, cipherText string) (string, error) {
+		plainText, err := v.Crypter.Decrypt(ctx, cipherText, v.secretAAD(name))
+		if err != nil {
+			return "", err
+		}
+		return envelope.Encrypt(ctx, plainText, v.secretAAD(name))
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to re-encrypt vault")
+	}
+
+	v.DataKey = envelope.WrappedKey()
+	v.envelope = envelope
+	v.Secrets = secrets
+	return nil
+}
+
+// Rotate re-encrypts every secret in the vault. With an empty keyring and key the current
+// primary version of the vault key is used, otherwise the vault is moved to the new key.
+// Vaults using envelope encryption also get a freshly generated data key wrapped for
+// the primary key and every recipient, and every secret is bound to its name with additional authenticated data
+// unless the vault keeps secrets Cloud Build can decrypt. The vault is left untouched if any secret fails to decrypt or re-encrypt
+func (v *Vault) Rotate(ctx context.Context, keyring, key, keyVersion string) error {
+	if keyVersion != "" && v.Backend != crypter.BackendKMSAsymmetric {
+		return errors.New("a key version can only be selected for kms-asymmetric vaults")
+	}
+
+	secretCrypter, err := v.secretCrypter()
+	if err != nil {
+		return err
+	}
+
+	plainTexts, err := v.batch(ctx, v.Secrets, func(ctx context.Context, name, cipherText string) (string, error) {
+		bytes, err := secretCrypter.Decrypt(ctx, cipherText, v.secretAAD(name))
+		return string(bytes), err
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to decrypt vault")
+	}
+
+	oldKeyring, oldKey, oldKeyVersion, oldPublicKey, oldCrypter := v.Keyring, v.Key, v.KeyVersion, v.PublicKey, v.Crypter
+	restore := func() {
+		v.Keyring, v.Key, v.KeyVersion, v.PublicKey, v.Crypter = oldKeyring, oldKey, oldKeyVersion, oldPublicKey, oldCrypter
+	}
+
+	if keyring != "" {
+		v.Keyring = keyring
+	}
+
+	if key != "" {
+		v.Key = key
+	}
+
+	if keyVersion != "" {
+		v.KeyVersion = keyVersion
+	}
+
+	if v.Keyring != oldKeyring || v.Key != oldKey || v.KeyVersion != oldKeyVersion {
+		if err := v.InitCrypter(); err != nil {
+			restore()
+			return err
+		}
+
+		// the cached public key belongs to a single key version
+		if v.Backend == crypter.BackendKMSAsymmetric {
+			if err := v.FetchPublicKey(ctx); err != nil {
+				restore()
+				return err
+			}
+		}
+	}
+
+	var encrypter crypter.Encrypter = v.Crypter
+	var envelope *crypter.Envelope
+	recipientKeys := map[*Recipient]string{}
+
+	if v.DataKey != "" {
+		if envelope, err = crypter.NewDataKey(ctx, v.Crypter); err != nil {
+			restore()
+			return err
+		}
+
+		for _, recipient := range v.Recipients {
+			if recipientKeys[recipient], err = envelope.Wrap(ctx, recipient); err != nil {
+				restore()
+				return errors.Wrapf(err, "failed to wrap data key for %s", recipient.Name())
+			}
+		}
+
+		encrypter = envelope
+	}
+
+	secrets, err := v.batch(ctx, plainTexts, func(ctx context.Context, name, plainText string) (string, error) {
+		var aad []byte
+		if !v.CloudBuild {
+			aad = secretAAD(v.Name, name)
+		}
+		return encrypter.Encrypt(ctx, []byte(plainText), aad)
+	})
+	if err != nil {
+		restore()
+		return errors.Wrap(err, "failed to re-encrypt vault")
+	}
+
+	if envelope != nil {
+		v.DataKey = envelope.WrappedKey()
+		v.envelope = envelope
+		for recipient, wrappedKey := range recipientKeys {
+			recipient.DataKey = wrappedKey
+		}
+	}
+
+	v.Secrets = secrets
+	v.AAD = !v.CloudBuild
+	return nil
+}
+
+// KmsSecrets returns the vault secrets encrypted directly with the backend key without
+// additional authenticated data, as required by consumers such as cloudbuild that decrypt
+// with KMS themselves. Vaults using envelope encryption or tamper protection can not be exported
+func (v *Vault) KmsSecrets() (map[string]string, error) {
+	if v.Backend != "" && v.Backend != crypter.BackendKMS {
+		return nil, fmt.Errorf("exporting KMS secrets requires a symmetric KMS key, this vault uses the %s backend", v.Backend)
+	}
+
+	if v.DataKey != "" || v.AAD {
+		return nil, fmt.Errorf("vault (%s) does not keep secrets Cloud Build can decrypt, create one with `gvault init --cloudbuild` or `gvault vaults clone %s NAME --cloudbuild`", v.Name, v.Name)
+	}
+
+	return v.Secrets, nil
+}
+
+// FetchPublicKey caches the public key of an asymmetric vault key in the vault
+func (v *Vault) FetchPublicKey(ctx context.Context) error {
+	if err := v.EnsureCrypter(); err != nil {
+		return err
+	}
+
+	asymmetric, ok := v.Crypter.(*crypter.AsymmetricCrypter)
+	if !ok {
+		return fmt.Errorf("the %s backend does not use public keys", v.Backend)
+	}
+
+	publicKey, err := asymmetric.FetchPublicKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	v.PublicKey = publicKey
 	return nil
 }
 
 func (v *Vault) validate() error {
-	if v.Project == "" {
-		return errors.New("No Goolge Cloud `Project` was specified")
+	if v.Backend == crypter.BackendKMSAsymmetric {
+		if v.KeyVersion == "" {
+			return errors.New("No Google Cloud KMS `KeyVersion` was specified")
+		}
+
+		if v.PublicKey == "" {
+			return errors.New("No public key is cached for the Google Cloud KMS key version")
+		}
 	}
 
-	if v.Keyring == "" {
-		return errors.New("No Google Cloud KMS `Keyring` was specified")
+	if v.Backend == crypter.BackendLocal {
+		if v.Key == "" {
+			return errors.New("No local `Key` was specified")
+		}
+	} else {
+		if v.Project == "" {
+			return errors.New("No Goolge Cloud `Project` was specified")
+		}
+
+		if v.Keyring == "" {
+			return errors.New("No Google Cloud KMS `Keyring` was specified")
+		}
+
+		if v.Location == "" {
+			return errors.New("No Google Cloud KMS Keyring `Location` was specified")
+		}
+
+		if v.Key == "" {
+			return errors.New("No Google Cloud KMS `Key` was specified")
+		}
 	}
 
-	if v.Location == "" {
-		return errors.New("No Google Cloud KMS Keyring `Location` was specified")
+	// vaults loaded from disk were verified when they were created, skipping the
+	// check allows changes like removing a secret without any credentials
+	if v.loaded {
+		return nil
 	}
 
-	if v.Key == "" {
-		return errors.New("No Google Cloud KMS `Key` was specified")
+	if crypterErr := v.EnsureCrypter(); crypterErr != nil {
+		return crypterErr
 	}
 
-	if _, encryptErr := v.Crypter.Encrypt([]byte("test")); encryptErr != nil {
+	if _, encryptErr := v.Crypter.Encrypt(context.Background(), []byte("test"), nil); encryptErr != nil {
 		return errors.Wrap(encryptErr, "failed to verify cryptoKey settings")
 	}
 
@@ -364,6 +805,7 @@ func New(config Config) *Vault {
 		Location: config.Location,
 		Keyring:  config.Keyring,
 		Key:      config.Key,
+		Backend:  config.Backend,
 		Secrets:  map[string]string{},
 	}
 }
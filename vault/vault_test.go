@@ -0,0 +1,67 @@
+package vault
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/sourcec0de/gvault/crypter"
+	"golang.org/x/net/context"
+)
+
+// chdirTemp changes into a new temporary directory that also holds local backend keys
+// and returns a function restoring the previous state
+func chdirTemp(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "gvault-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	keyDir := crypter.LocalKeyDir
+	crypter.LocalKeyDir = dir
+
+	return func() {
+		crypter.LocalKeyDir = keyDir
+		os.Chdir(cwd)
+		os.RemoveAll(dir)
+	}
+}
+
+// newLocalVault creates a tamper protected vault encrypted with a new local backend key
+func newLocalVault(t *testing.T, name string) *Vault {
+	v := New(Config{Name: name, Key: name, Backend: crypter.BackendLocal})
+	if err := v.InitCrypter(); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Crypter.(*crypter.LocalCrypter).CreateKeyIfNotExists(); err != nil {
+		t.Fatal(err)
+	}
+	v.AAD = true
+	return v
+}
+
+// setSecrets adds plain text secrets to v
+func setSecrets(t *testing.T, v *Vault, secrets map[string]string) {
+	for key, value := range secrets {
+		if err := v.SetSecret(context.Background(), key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// copySecrets a copy of the stored cipher texts of v
+func copySecrets(v *Vault) map[string]string {
+	secrets := map[string]string{}
+	for key, value := range v.Secrets {
+		secrets[key] = value
+	}
+	return secrets
+}